@@ -1,668 +1,440 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
+	"strconv"
 	"strings"
-	"time"
-)
 
-type Collection struct {
-	Info     Info       `json:"info"`
-	Items    []Item     `json:"item"`
-	Variable []Variable `json:"variable"`
-}
+	"github.com/FrantPRO/jetbrains-http-to-postman/httpfile"
+	"github.com/FrantPRO/jetbrains-http-to-postman/postman"
+)
 
-type Info struct {
-	Name        string `json:"name"`
-	Description string `json:"description,omitempty"`
-	Schema      string `json:"schema"`
-}
+const (
+	envFileName        = "http-client.env.json"
+	privateEnvFileName = "http-client.private.env.json"
+)
 
-type Item struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description,omitempty"`
-	Item        []Item  `json:"item,omitempty"`
-	Request     Request `json:"request,omitempty"`
+// environment is the content of the loaded environment files
+type environment struct {
+	// values and private hold each variable once, with the value of the last file defining it.
+	// Values last defined by a *.private.env.json file are in private.
+	values  httpfile.Environment
+	private httpfile.Environment
+	// files are the loaded files in order, for their object sections
+	files []*httpfile.EnvironmentFile
 }
 
-type Request struct {
-	Method string   `json:"method"`
-	Header []Header `json:"header"`
-	Body   Body     `json:"body"`
-	URL    URL      `json:"url"`
-}
+// add merges a loaded file into the environment, over the files added before
+func (e *environment) add(file *httpfile.EnvironmentFile, private bool) {
+	set, other := &e.values, &e.private
+	if private {
+		set, other = &e.private, &e.values
+	}
+	if *set == nil {
+		*set = make(httpfile.Environment)
+	}
+	for name, values := range file.Variables {
+		if (*set)[name] == nil {
+			(*set)[name] = make(map[string]string)
+		}
+		for key, value := range values {
+			(*set)[name][key] = value
+			delete((*other)[name], key)
+		}
+	}
 
-type Header struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
-	Type  string `json:"type"`
+	e.files = append(e.files, file)
 }
 
-type Body struct {
-	Mode    string                 `json:"mode,omitempty"`
-	Raw     string                 `json:"raw,omitempty"`
-	Options map[string]interface{} `json:"options,omitempty"`
-}
+// loadEnvironment loads the given environment files, later files overriding earlier ones. Files named
+// *.private.env.json hold private values. Without files, http-client.env.json and
+// http-client.private.env.json are taken from the closest directory holding either of them, starting
+// at the input file's directory and going up to the repository root.
+func loadEnvironment(inputFilePath string, envFiles []string) (environment, error) {
+	var env environment
+	if len(envFiles) > 0 {
+		for _, path := range envFiles {
+			file, err := loadEnvironmentFile(path)
+			if err != nil {
+				return environment{}, err
+			}
+			env.add(file, strings.HasSuffix(filepath.Base(path), ".private.env.json"))
+		}
+		return env, nil
+	}
 
-type URL struct {
-	Raw      string       `json:"raw"`
-	Protocol string       `json:"protocol,omitempty"`
-	Host     []string     `json:"host,omitempty"`
-	Path     []string     `json:"path,omitempty"`
-	Query    []QueryParam `json:"query,omitempty"`
-	Variable []Variable   `json:"variable,omitempty"`
-}
+	dir, err := findEnvironmentDir(filepath.Dir(inputFilePath))
+	if err != nil {
+		return environment{}, err
+	}
 
-type QueryParam struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
-}
+	file, err := loadEnvironmentFile(filepath.Join(dir, envFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return environment{}, err
+	}
+	if file != nil {
+		env.add(file, false)
+	}
 
-type Variable struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
-	Type  string `json:"type,omitempty"`
+	private, privateErr := loadEnvironmentFile(filepath.Join(dir, privateEnvFileName))
+	if os.IsNotExist(privateErr) {
+		return env, err
+	}
+	if privateErr != nil {
+		return environment{}, privateErr
+	}
+	env.add(private, true)
+	return env, nil
 }
 
-type Group struct {
-	Name  string
-	Items []Item
-}
+// findEnvironmentDir returns the closest directory from dir up holding an environment file,
+// the search stops at the repository root
+func findEnvironmentDir(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
 
-type Environment map[string]map[string]string
+	start := dir
+	for {
+		for _, name := range []string{envFileName, privateEnvFileName} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return dir, nil
+			}
+		}
 
-// detectVariables finds all variables in the format {{variableName}} in the text
-func detectVariables(text string) []string {
-	varRegex := regexp.MustCompile(`\{\{(\w+)\}\}`)
-	matches := varRegex.FindAllStringSubmatch(text, -1)
-	var variables []string
-	for _, match := range matches {
-		if len(match) > 1 {
-			variables = append(variables, match[1])
+		parent := filepath.Dir(dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil || parent == dir {
+			return "", fmt.Errorf("%s not found in %s or its parent directories: %w", envFileName, start, os.ErrNotExist)
 		}
+		dir = parent
 	}
-	return variables
 }
 
-// loadEnvironment loads the http-client.env.json file from the input file's directory
-func loadEnvironment(inputFilePath string) (Environment, error) {
-	dir := filepath.Dir(inputFilePath)
-	envFile := filepath.Join(dir, "http-client.env.json")
-
-	data, err := os.ReadFile(envFile)
+// loadEnvironmentFile loads a single environment file
+func loadEnvironmentFile(path string) (*httpfile.EnvironmentFile, error) {
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
-	var env Environment
-	err = json.Unmarshal(data, &env)
+	env, err := httpfile.ParseEnvironmentFile(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse http-client.env.json: %v", err)
+		return nil, fmt.Errorf("failed to parse %s: %v", filepath.Base(path), err)
+	}
+	fmt.Fprintf(os.Stderr, "Using environment file %s\n", path)
+	for _, warning := range env.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", filepath.Base(path), warning)
 	}
 
 	return env, nil
 }
 
-func main() {
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: go run main.go <input.http> <output.json>")
-		os.Exit(1)
+// loadDotEnv loads the .env file from the input file's directory, a missing file is not an error
+func loadDotEnv(inputFilePath string) (map[string]string, error) {
+	file, err := os.Open(filepath.Join(filepath.Dir(inputFilePath), ".env"))
+	if os.IsNotExist(err) {
+		return nil, nil
 	}
-
-	inputFile := os.Args[1]
-	outputFile := os.Args[2]
-
-	err := convertHTTPToPostman(inputFile, outputFile)
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		return nil, err
 	}
+	defer file.Close()
 
-	fmt.Printf("Successfully converted %s to %s\n", inputFile, outputFile)
-}
+	values, err := httpfile.ParseDotEnv(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse .env: %v", err)
+	}
 
-// resetRequest resets all request-related variables
-func resetRequest(item *Item, req *Request, headers *[]Header, body *Body, url *URL, query *[]QueryParam, data *strings.Builder, startedJSON *bool, currentRequestName *string, requestVars *map[string]string, inRequestScript *bool) {
-	*item = Item{}
-	*req = Request{
-		Header: []Header{},
-		Body:   Body{},
-		URL:    URL{Query: []QueryParam{}},
-	}
-	*headers = []Header{}
-	*body = Body{}
-	*url = URL{Variable: []Variable{}}
-	*query = []QueryParam{}
-	data.Reset()
-	*startedJSON = false
-	*currentRequestName = ""
-	*requestVars = make(map[string]string)
-	*inRequestScript = false
+	return values, nil
 }
 
-// parseURL parses a URL and sets the appropriate fields for Postman format
-func parseURL(rawURL string, url *URL, localVars map[string]string, requestVars map[string]string) {
-	var pathVariables []Variable
-	varRegex := regexp.MustCompile(`\{\{(\w+)\}\}`)
-
-	// Handle variables in URL by keeping them as-is
-	if strings.Contains(rawURL, "{{baseUrl}}") || strings.Contains(rawURL, "{{baseURL}}") {
-		// For URLs with baseUrl variable, set host to the variable
-		url.Host = []string{"{{baseUrl}}"}
-		// Parse the path part - split by / and remove query params
-		pathPart := rawURL
-		if strings.Contains(pathPart, "?") {
-			pathPart = strings.Split(pathPart, "?")[0]
-		}
-
-		if strings.Contains(pathPart, "/") {
-			parts := strings.Split(pathPart, "/")
-			if len(parts) > 1 {
-				var pathParts []string
-				for i, part := range parts {
-					if i == 0 {
-						continue // Skip the baseUrl part
-					}
-					if part != "" {
-						// Check if this path segment contains variables
-						if varRegex.MatchString(part) {
-							convertedPart := part
-							matches := varRegex.FindAllStringSubmatch(part, -1)
-							for _, match := range matches {
-								if len(match) > 1 {
-									varName := match[1]
-									// Convert {{variable}} to :variable for path
-									convertedPart = strings.ReplaceAll(convertedPart, match[0], ":"+varName)
-
-									// Determine variable value from different scopes
-									varValue := ""
-									if requestVars != nil {
-										if val, exists := requestVars[varName]; exists {
-											varValue = val
-										}
-									}
-									if varValue == "" {
-										if val, exists := localVars[varName]; exists {
-											varValue = val
-										}
-									}
-
-									// Add to path variables
-									pathVariables = append(pathVariables, Variable{
-										Key:   varName,
-										Value: varValue,
-										Type:  "string",
-									})
-								}
-							}
-							pathParts = append(pathParts, convertedPart)
-						} else {
-							pathParts = append(pathParts, part)
-						}
-					}
-				}
-				url.Path = pathParts
-			}
+// processEnv returns the OS environment as a map
+func processEnv() map[string]string {
+	values := make(map[string]string)
+	for _, entry := range os.Environ() {
+		if key, value, found := strings.Cut(entry, "="); found {
+			values[key] = value
 		}
-
-		if len(pathVariables) > 0 {
-			url.Variable = pathVariables
-		}
-		return
 	}
+	return values
+}
 
-	// Standard URL parsing
-	if strings.Contains(rawURL, "://") {
-		protocolParts := strings.Split(rawURL, "://")
-		url.Protocol = protocolParts[0]
+// config holds the command line options
+type config struct {
+	// EnvName is the environment of http-client.env.json to use, empty for none
+	EnvName string
+	// BodyFiles selects how "< ./file" bodies are converted
+	BodyFiles postman.BodyFileMode
+	// SystemVariables selects how $env, $processEnv and $dotenv variables are converted
+	SystemVariables postman.SystemVariableMode
+	// ExportEnvironments writes a Postman environment per environment next to the collection
+	ExportEnvironments bool
+	// BlankSecrets leaves the values of http-client.private.env.json out of the output
+	BlankSecrets bool
+	// Lenient declares unresolved variables empty instead of failing when the environment file is missing
+	Lenient bool
+	// HoistAuth moves auth shared by all requests up to their folder or the collection
+	HoistAuth bool
+	// EnvFiles are the environment files to load instead of searching for http-client.env.json
+	EnvFiles []string
+}
 
-		cleanURL := protocolParts[1]
-		if strings.Contains(cleanURL, "?") {
-			cleanURL = strings.Split(cleanURL, "?")[0]
-		}
+// stringList is a flag that can be repeated
+type stringList []string
 
-		if strings.Contains(cleanURL, "/") {
-			urlParts := strings.Split(cleanURL, "/")
-			url.Host = strings.Split(urlParts[0], ".")
-			if len(urlParts) > 1 {
-				url.Path = urlParts[1:]
-			}
-		} else {
-			url.Host = strings.Split(cleanURL, ".")
-		}
-	} else {
-		urlParts := strings.Split(rawURL, "/")
-		if len(urlParts) > 0 {
-			url.Host = strings.Split(urlParts[0], ".")
-		}
-	}
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
 }
 
-func convertHTTPToPostman(inputFile, outputFile string) error {
-	file, err := os.Open(inputFile)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
 
-	// First, read the entire file to detect variables
-	file.Seek(0, 0) // Reset file pointer
-	var fileContent strings.Builder
-	fileScanner := bufio.NewScanner(file)
-	for fileScanner.Scan() {
-		fileContent.WriteString(fileScanner.Text() + "\n")
+func main() {
+	var cfg config
+	envName := flag.String("env", "dev", "environment of http-client.env.json to take variable values from, or none")
+	bodyFiles := flag.String("body-files", "inline", "how to convert \"< ./file\" bodies: inline or reference")
+	flag.BoolVar(&cfg.ExportEnvironments, "export-envs", false, "write a Postman environment file per environment of http-client.env.json next to the collection")
+	flag.Var((*stringList)(&cfg.EnvFiles), "env-file", "environment file to load, may be repeated with later files overriding earlier ones (default: http-client.env.json searched from the input file's directory up)")
+	flag.BoolVar(&cfg.HoistAuth, "hoist-auth", false, "move auth shared by all requests of a folder to the folder, and shared by all items to the collection")
+	flag.BoolVar(&cfg.BlankSecrets, "blank-secrets", false, "leave the values of http-client.private.env.json out of the output")
+	unresolved := flag.String("unresolved", "strict", "what to do with variables without a value when the environment file is missing: strict fails, lenient declares them empty")
+	systemVars := flag.String("system-vars", "variables", "how to convert $env, $processEnv and $dotenv variables: variables or resolve")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: jetbrains-http-to-postman [options] <input.http> <output.json>")
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
-	// Detect variables in the file content
-	allVariables := detectVariables(fileContent.String())
-
-	// Load environment variables
-	env, envErr := loadEnvironment(inputFile)
-
-	// Check if variables exist but env file doesn't
-	if len(allVariables) > 0 && envErr != nil {
-		return fmt.Errorf("variables found in input file (%v) but http-client.env.json is missing or invalid: %v", allVariables, envErr)
-	}
-
-	// We'll use "dev" as the default environment name
-	envName := "dev"
-
-	// Storage for local variables (@var_name = value)
-	localVariables := make(map[string]string)
-
-	// Reset file pointer for actual parsing
-	file.Seek(0, 0)
-
-	var groups []Group
-	var currentGroup *Group
-	var allItems []Item
-	var hasGroupSeparators bool
-	var item Item
-	var req Request
-	var headers []Header
-	var body Body
-	var url URL
-	var query []QueryParam
-	var data strings.Builder
-	count := 0
-	startedJSON := false
-	var currentRequestName string
-	var currentRequestDescription string
-	var requestVariables map[string]string // Request-level variables for current request
-	var inRequestScript bool               // Flag to track if we're inside a request script block
-
-	// Initialize first item
-	item = Item{}
-	req = Request{
-		Header: []Header{},
-		Body:   Body{},
-		URL:    URL{Query: []QueryParam{}, Variable: []Variable{}},
-	}
-	requestVariables = make(map[string]string)
-
-	// Regex patterns
-	httpMethodRegex := regexp.MustCompile(`^(GET|PUT|POST|DELETE|OPTIONS)\s+.+`)
-	groupRegex := regexp.MustCompile(`^#\s*@group_name\s+(.+)$`)
-	nameRegex := regexp.MustCompile(`^#\s*@name\s+(\w+)$`)
-	descriptionRegex := regexp.MustCompile(`^//\s*(.+)$`)
-	requestSeparatorRegex := regexp.MustCompile(`^###.*$`)
-	localVariableRegex := regexp.MustCompile(`^@(\w+)\s*=\s*(.+)$`)
-	requestVariableRegex := regexp.MustCompile(`request\.variables\.set\("([^"]+)",\s*"([^"]+)"\)`)
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		switch {
-		case line == "":
-			// Skip empty lines
-			continue
-
-		case groupRegex.MatchString(line):
-			// Group definition: # @group_name PRODUCTS
-			matches := groupRegex.FindStringSubmatch(line)
-			if len(matches) > 1 {
-				groupName := strings.TrimSpace(matches[1])
-
-				// Add current group to groups if it has items
-				if currentGroup != nil && len(currentGroup.Items) > 0 {
-					groups = append(groups, *currentGroup)
-				}
-
-				currentGroup = &Group{Name: groupName, Items: []Item{}}
-				hasGroupSeparators = true
-			}
-			continue
-
-		case nameRegex.MatchString(line):
-			// Extract @name comment
-			matches := nameRegex.FindStringSubmatch(line)
-			if len(matches) > 1 {
-				currentRequestName = matches[1]
-			}
-			continue
-
-		case descriptionRegex.MatchString(line):
-			// Extract description from // comment
-			matches := descriptionRegex.FindStringSubmatch(line)
-			if len(matches) > 1 {
-				currentRequestDescription = matches[1]
-			}
-			continue
-
-		case localVariableRegex.MatchString(line):
-			// Parse local variable: @var_name = value
-			matches := localVariableRegex.FindStringSubmatch(line)
-			if len(matches) > 2 {
-				varName := matches[1]
-				varValue := strings.TrimSpace(matches[2])
-				localVariables[varName] = varValue
-			}
-			continue
-
-		case strings.HasPrefix(line, "<") && strings.Contains(line, "{%"):
-			// Start of request script block (single line or multi-line)
-			if strings.Contains(line, "%}") {
-				// Single line script block: < {% ... %}
-				if requestVariableRegex.MatchString(line) {
-					matches := requestVariableRegex.FindStringSubmatch(line)
-					if len(matches) > 2 {
-						varName := matches[1]
-						varValue := matches[2]
-						requestVariables[varName] = varValue
-					}
-				}
-			} else {
-				// Multi-line script block starts
-				inRequestScript = true
-				if requestVariableRegex.MatchString(line) {
-					matches := requestVariableRegex.FindStringSubmatch(line)
-					if len(matches) > 2 {
-						varName := matches[1]
-						varValue := matches[2]
-						requestVariables[varName] = varValue
-					}
-				}
-			}
-			continue
-
-		case strings.Contains(line, "%}") && inRequestScript:
-			// End of multi-line request script block
-			inRequestScript = false
-			if requestVariableRegex.MatchString(line) {
-				matches := requestVariableRegex.FindStringSubmatch(line)
-				if len(matches) > 2 {
-					varName := matches[1]
-					varValue := matches[2]
-					requestVariables[varName] = varValue
-				}
-			}
-			continue
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
 
-		case inRequestScript:
-			// Inside multi-line request script block - check for variable assignments
-			if requestVariableRegex.MatchString(line) {
-				matches := requestVariableRegex.FindStringSubmatch(line)
-				if len(matches) > 2 {
-					varName := matches[1]
-					varValue := matches[2]
-					requestVariables[varName] = varValue
-				}
-			}
-			continue
+	switch *bodyFiles {
+	case "inline":
+		cfg.BodyFiles = postman.BodyFileInline
+	case "reference":
+		cfg.BodyFiles = postman.BodyFileReference
+	default:
+		fmt.Printf("Error: unknown --body-files value %q, expected inline or reference\n", *bodyFiles)
+		os.Exit(1)
+	}
 
-		case requestSeparatorRegex.MatchString(line):
-			// End of request: ### - save current request
-			if req.Method != "" && url.Raw != "" {
-				req.Header = headers
-				req.Body = body
-				req.URL = url
-				req.URL.Query = query
-				item.Request = req
-
-				// Set name and description
-				if currentRequestName != "" {
-					item.Name = currentRequestName
-				} else {
-					count++
-					item.Name = fmt.Sprintf("request-%d", count)
-				}
-
-				if currentRequestDescription != "" {
-					item.Description = currentRequestDescription
-				}
-
-				// Add to group or all items
-				if hasGroupSeparators && currentGroup != nil {
-					currentGroup.Items = append(currentGroup.Items, item)
-				} else {
-					allItems = append(allItems, item)
-				}
-			}
+	cfg.EnvName = *envName
+	if cfg.EnvName == "none" {
+		cfg.EnvName = ""
+	}
 
-			// Reset for next request
-			resetRequest(&item, &req, &headers, &body, &url, &query, &data, &startedJSON, &currentRequestName, &requestVariables, &inRequestScript)
-			currentRequestDescription = ""
-			continue
+	switch *unresolved {
+	case "strict":
+	case "lenient":
+		cfg.Lenient = true
+	default:
+		fmt.Printf("Error: unknown --unresolved value %q, expected strict or lenient\n", *unresolved)
+		os.Exit(1)
+	}
 
-		case strings.HasPrefix(line, "#"):
-			// Skip other comments
-			continue
+	switch *systemVars {
+	case "variables":
+		cfg.SystemVariables = postman.SystemVariablesPlaceholder
+	case "resolve":
+		cfg.SystemVariables = postman.SystemVariablesResolve
+	default:
+		fmt.Printf("Error: unknown --system-vars value %q, expected variables or resolve\n", *systemVars)
+		os.Exit(1)
+	}
 
-		case httpMethodRegex.MatchString(line):
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				req.Method = parts[0]
-				rawURL := parts[1]
-
-				// Parse URL and convert to Postman format
-				url.Raw = rawURL
-				parseURL(rawURL, &url, localVariables, requestVariables)
-
-				// Parse query parameters
-				if strings.Contains(rawURL, "?") {
-					queryParts := strings.Split(rawURL, "?")
-					if len(queryParts) > 1 {
-						queryString := queryParts[1]
-						params := strings.Split(queryString, "&")
-						for _, param := range params {
-							if strings.Contains(param, "=") {
-								kv := strings.Split(param, "=")
-								query = append(query, QueryParam{
-									Key:   strings.TrimSpace(kv[0]),
-									Value: strings.TrimSpace(kv[1]),
-								})
-							}
-						}
-					}
-				}
-			}
+	inputFile := flag.Arg(0)
+	outputFile := flag.Arg(1)
 
-		case strings.HasPrefix(line, "{"):
-			// Start of JSON body
-			body.Mode = "raw"
-			body.Options = map[string]interface{}{
-				"raw": map[string]interface{}{
-					"language": "json",
-				},
-			}
-			startedJSON = true
-
-			if strings.HasSuffix(line, "}") {
-				// Single line JSON
-				body.Raw = strings.TrimSpace(line)
-				startedJSON = false
-			} else {
-				// Multi-line JSON starts
-				data.WriteString(line)
-				data.WriteString("\n")
-			}
+	err := convertHTTPToPostman(inputFile, outputFile, cfg)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 
-		case strings.Contains(line, ":") && !startedJSON:
-			// Parse headers
-			headerParts := strings.SplitN(line, ":", 2)
-			if len(headerParts) == 2 {
-				key := strings.TrimSpace(headerParts[0])
-				value := strings.TrimSpace(headerParts[1])
-
-				header := Header{
-					Key:   key,
-					Value: value,
-					Type:  "text",
-				}
-				headers = append(headers, header)
-			}
+	fmt.Printf("Successfully converted %s to %s\n", inputFile, outputFile)
+}
 
-		case strings.Contains(line, ":") && startedJSON:
-			// Continue JSON body
-			data.WriteString(line + "\n")
+func convertHTTPToPostman(inputFile, outputFile string, cfg config) error {
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
 
-		case strings.HasSuffix(line, "}") && startedJSON:
-			// End of JSON body
-			data.WriteString(line)
-			body.Raw = strings.TrimSpace(data.String())
-			startedJSON = false
+	parsed, err := httpfile.Parse(file, httpfile.Options{Filename: inputFile})
+	if err != nil {
+		return err
+	}
 
-		case startedJSON:
-			// Any other line while parsing JSON
-			data.WriteString(line + "\n")
+	// Load environment variables
+	env, envErr := loadEnvironment(inputFile, cfg.EnvFiles)
 
-		}
+	if len(cfg.EnvFiles) > 0 && envErr != nil {
+		// Explicitly requested files must load
+		return envErr
+	}
+	if cfg.ExportEnvironments && envErr != nil {
+		return fmt.Errorf("--export-envs needs http-client.env.json: %v", envErr)
 	}
 
-	if err := scanner.Err(); err != nil {
+	baseDir, err := filepath.Abs(filepath.Dir(inputFile))
+	if err != nil {
 		return err
 	}
 
-	// Handle the last request if it doesn't end with ###
-	if req.Method != "" && url.Raw != "" {
-		req.Header = headers
-		req.Body = body
-		req.URL = url
-		req.URL.Query = query
-		item.Request = req
-
-		// Set name and description
-		if currentRequestName != "" {
-			item.Name = currentRequestName
-		} else {
-			count++
-			item.Name = fmt.Sprintf("request-%d", count)
+	opts := postman.Options{
+		Environment:        env.values,
+		PrivateEnvironment: env.private,
+		BlankSecrets:       cfg.BlankSecrets,
+		EnvName:            cfg.EnvName,
+		BaseDir:            baseDir,
+		BodyFiles:          cfg.BodyFiles,
+		SystemVariables:    cfg.SystemVariables,
+		ExportEnvironments: cfg.ExportEnvironments,
+		HoistAuth:          cfg.HoistAuth,
+	}
+	opts.Auth, err = httpfile.AuthConfigs(cfg.EnvName, env.files...)
+	if err != nil {
+		return err
+	}
+	if cfg.SystemVariables == postman.SystemVariablesResolve {
+		opts.ProcessEnv = processEnv()
+		opts.DotEnv, err = loadDotEnv(inputFile)
+		if err != nil {
+			return err
 		}
+	}
 
-		if currentRequestDescription != "" {
-			item.Description = currentRequestDescription
-		}
+	var report postman.Report
+	opts.Report = &report
+	collection, err := postman.Convert(parsed, opts)
+	if err != nil {
+		return err
+	}
 
-		if hasGroupSeparators && currentGroup != nil {
-			currentGroup.Items = append(currentGroup.Items, item)
-		} else {
-			allItems = append(allItems, item)
+	// Report the variables nothing gives a value. Strict mode doesn't write a collection with them
+	// when the environment file is missing or invalid, no environment means empty values are wanted.
+	uses := append(parsed.VariableUses(), report.FileVariables...)
+	unresolved := unresolvedVariables(uses, definedVariables(parsed, opts, report))
+	if len(unresolved) > 0 && envErr != nil && !cfg.Lenient && cfg.EnvName != "" {
+		var list []string
+		for _, v := range unresolved {
+			list = append(list, v.String())
 		}
+		return fmt.Errorf("unresolved variables %s, environment file is missing or invalid: %v", strings.Join(list, ", "), envErr)
 	}
-
-	// Add the current group to groups if it has items
-	if currentGroup != nil && len(currentGroup.Items) > 0 {
-		groups = append(groups, *currentGroup)
+	for _, v := range unresolved {
+		fmt.Fprintf(os.Stderr, "Warning: unresolved variable %s declared empty\n", v)
+	}
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
 	}
 
-	// Create collection variables from detected variables
-	var collectionVariables []Variable
-	uniqueVars := make(map[string]bool)
+	output, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
 
-	// Add detected variables from file content
-	for _, varName := range allVariables {
-		if !uniqueVars[varName] {
-			uniqueVars[varName] = true
-			value := ""
+	if err := postman.WriteCollection(output, collection); err != nil {
+		return err
+	}
+	if err := output.Close(); err != nil {
+		return err
+	}
 
-			// Check local variables first
-			if localValue, exists := localVariables[varName]; exists {
-				value = localValue
-			} else if env != nil && env[envName] != nil {
-				// Then check environment variables
-				if val, exists := env[envName][varName]; exists {
-					value = val
-				}
+	if cfg.ExportEnvironments {
+		for _, environment := range postman.Environments(opts) {
+			path := filepath.Join(filepath.Dir(outputFile), environment.Name+".postman_environment.json")
+			if err := writeEnvironment(path, environment); err != nil {
+				return err
 			}
-
-			collectionVariables = append(collectionVariables, Variable{
-				Key:   varName,
-				Value: value,
-				Type:  "string",
-			})
 		}
 	}
+	return nil
+}
 
-	// Add any local variables that weren't detected in the content
-	for varName, varValue := range localVariables {
-		if !uniqueVars[varName] {
-			collectionVariables = append(collectionVariables, Variable{
-				Key:   varName,
-				Value: varValue,
-				Type:  "string",
-			})
-		}
+// unresolvedVariable is a variable without a value and the lines it is used on
+type unresolvedVariable struct {
+	name  string
+	lines []int
+}
+
+func (v unresolvedVariable) String() string {
+	lines := make([]string, len(v.lines))
+	for i, line := range v.lines {
+		lines[i] = strconv.Itoa(line)
 	}
+	return fmt.Sprintf("{{%s}} (line %s)", v.name, strings.Join(lines, ", "))
+}
 
-	// Note: Request-level variables are handled per-request and not added to global collection variables
-	// They are applied during URL and content processing for each specific request
-
-	// Convert groups to Postman folder structure
-	var items []Item
-
-	// If we have groups, create folder structure
-	if hasGroupSeparators && len(groups) > 0 {
-		for _, group := range groups {
-			if len(group.Items) > 0 {
-				// Filter out any empty request items
-				var validItems []Item
-				for _, item := range group.Items {
-					if item.Request.Method != "" {
-						validItems = append(validItems, item)
-					}
-				}
-
-				if len(validItems) > 0 {
-					groupItem := Item{
-						Name: group.Name,
-						Item: validItems,
-						// Don't include Request for group items
-					}
-					items = append(items, groupItem)
-				}
-			}
+// definedVariables returns the variables given a value by the file, its response handlers or the
+// environment, all environments count when they are exported
+func definedVariables(file *httpfile.File, opts postman.Options, report postman.Report) map[string]bool {
+	defined := make(map[string]bool)
+	for _, block := range file.Blocks {
+		for _, v := range block.Variables {
+			defined[v.Name] = true
 		}
-	} else {
-		// No groups, add items directly
-		for _, item := range allItems {
-			if item.Request.Method != "" {
-				items = append(items, item)
+	}
+	for _, name := range report.ProducedVariables {
+		defined[name] = true
+	}
+
+	env := httpfile.MergeEnvironments(opts.Environment, opts.PrivateEnvironment)
+	for name, values := range env {
+		if opts.ExportEnvironments || name == opts.EnvName {
+			for key := range values {
+				defined[key] = true
 			}
 		}
 	}
+	return defined
+}
+
+// unresolvedVariables returns the used variables that are not defined, in order of first use
+func unresolvedVariables(uses []httpfile.VariableUse, defined map[string]bool) []unresolvedVariable {
+	sort.SliceStable(uses, func(i, j int) bool { return uses[i].Line < uses[j].Line })
 
-	// Create collection
-	today := time.Now().Format("20060102150405")
-	collection := Collection{
-		Info: Info{
-			Name:   fmt.Sprintf("jb-export-%s", today),
-			Schema: "https://schema.getpostman.com/json/collection/v2.1.0/collection.json",
-		},
-		Items:    items,
-		Variable: collectionVariables,
+	var unresolved []unresolvedVariable
+	index := make(map[string]int)
+	for _, use := range uses {
+		if defined[use.Name] {
+			continue
+		}
+		i, ok := index[use.Name]
+		if !ok {
+			i = len(unresolved)
+			index[use.Name] = i
+			unresolved = append(unresolved, unresolvedVariable{name: use.Name})
+		}
+		if lines := unresolved[i].lines; len(lines) == 0 || lines[len(lines)-1] != use.Line {
+			unresolved[i].lines = append(lines, use.Line)
+		}
 	}
+	return unresolved
+}
 
-	// Write output file
-	output, err := json.MarshalIndent(collection, "", "  ")
+// writeEnvironment writes a Postman environment file
+func writeEnvironment(path string, environment postman.Environment) error {
+	output, err := os.Create(path)
 	if err != nil {
 		return err
 	}
+	defer output.Close()
 
-	return os.WriteFile(outputFile, output, 0644)
+	if err := postman.WriteEnvironment(output, environment); err != nil {
+		return err
+	}
+	return output.Close()
 }
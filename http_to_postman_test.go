@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/FrantPRO/jetbrains-http-to-postman/postman"
 )
 
 // Test helper functions
@@ -22,13 +24,13 @@ func createTempFile(t *testing.T, content string) string {
 	return tmpFile
 }
 
-func readJSONFile(t *testing.T, filename string) Collection {
+func readJSONFile(t *testing.T, filename string) postman.Collection {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		t.Fatalf("Failed to read JSON file: %v", err)
 	}
 
-	var collection Collection
+	var collection postman.Collection
 	err = json.Unmarshal(data, &collection)
 	if err != nil {
 		t.Fatalf("Failed to unmarshal JSON: %v", err)
@@ -47,7 +49,7 @@ Authorization: Bearer token123
 	inputFile := createTempFile(t, httpContent)
 	outputFile := filepath.Join(t.TempDir(), "output.json")
 
-	err := convertHTTPToPostman(inputFile, outputFile)
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
 	if err != nil {
 		t.Fatalf("Conversion failed: %v", err)
 	}
@@ -86,9 +88,9 @@ Authorization: Bearer token123
 		t.Errorf("Expected path %v, got %v", expectedPath, item.Request.URL.Path)
 	}
 
-	// Check headers
-	if len(item.Request.Header) != 2 {
-		t.Errorf("Expected 2 headers, got %d", len(item.Request.Header))
+	// Check headers, the Authorization header becomes the request auth
+	if len(item.Request.Header) != 1 {
+		t.Fatalf("Expected 1 header, got %d", len(item.Request.Header))
 	}
 
 	acceptHeader := item.Request.Header[0]
@@ -96,9 +98,9 @@ Authorization: Bearer token123
 		t.Errorf("Expected Accept header, got %v", acceptHeader)
 	}
 
-	authHeader := item.Request.Header[1]
-	if authHeader.Key != "Authorization" || authHeader.Value != "Bearer token123" {
-		t.Errorf("Expected Authorization header, got %v", authHeader)
+	auth := item.Request.Auth
+	if auth == nil || auth.Type != "bearer" || len(auth.Bearer) != 1 || auth.Bearer[0].Value != "token123" {
+		t.Errorf("Expected bearer auth with token123, got %+v", auth)
 	}
 }
 
@@ -116,7 +118,7 @@ Content-Type: application/json
 	inputFile := createTempFile(t, httpContent)
 	outputFile := filepath.Join(t.TempDir(), "output.json")
 
-	err := convertHTTPToPostman(inputFile, outputFile)
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
 	if err != nil {
 		t.Fatalf("Conversion failed: %v", err)
 	}
@@ -177,7 +179,7 @@ Accept: application/json
 	inputFile := createTempFile(t, httpContent)
 	outputFile := filepath.Join(t.TempDir(), "output.json")
 
-	err := convertHTTPToPostman(inputFile, outputFile)
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
 	if err != nil {
 		t.Fatalf("Conversion failed: %v", err)
 	}
@@ -191,7 +193,7 @@ Accept: application/json
 	item := collection.Items[0]
 
 	// Check query parameters
-	expectedParams := []QueryParam{
+	expectedParams := []postman.QueryParam{
 		{Key: "page", Value: "1"},
 		{Key: "limit", Value: "10"},
 		{Key: "sort", Value: "name"},
@@ -209,6 +211,39 @@ Accept: application/json
 	}
 }
 
+func TestMultiLineQueryParameters(t *testing.T) {
+	httpContent := `GET https://api.example.com/users
+    ?page=1
+    &limit=10
+    &sort=name
+Accept: application/json
+
+###`
+
+	inputFile := createTempFile(t, httpContent)
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	collection := readJSONFile(t, outputFile)
+
+	item := collection.Items[0]
+	if item.Request.URL.Raw != "https://api.example.com/users?page=1&limit=10&sort=name" {
+		t.Errorf("Expected joined URL, got '%s'", item.Request.URL.Raw)
+	}
+
+	if len(item.Request.URL.Query) != 3 {
+		t.Errorf("Expected 3 query params, got %v", item.Request.URL.Query)
+	}
+
+	if len(item.Request.Header) != 1 {
+		t.Errorf("Expected 1 header, got %v", item.Request.Header)
+	}
+}
+
 func TestMultipleRequests(t *testing.T) {
 	httpContent := `GET https://api.example.com/users
 Accept: application/json
@@ -232,7 +267,7 @@ Authorization: Bearer token123
 	inputFile := createTempFile(t, httpContent)
 	outputFile := filepath.Join(t.TempDir(), "output.json")
 
-	err := convertHTTPToPostman(inputFile, outputFile)
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
 	if err != nil {
 		t.Fatalf("Conversion failed: %v", err)
 	}
@@ -278,7 +313,7 @@ Accept: application/json
 	inputFile := createTempFile(t, httpContent)
 	outputFile := filepath.Join(t.TempDir(), "output.json")
 
-	err := convertHTTPToPostman(inputFile, outputFile)
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
 	if err != nil {
 		t.Fatalf("Conversion failed: %v", err)
 	}
@@ -304,7 +339,7 @@ func TestCollectionMetadata(t *testing.T) {
 	inputFile := createTempFile(t, httpContent)
 	outputFile := filepath.Join(t.TempDir(), "output.json")
 
-	err := convertHTTPToPostman(inputFile, outputFile)
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
 	if err != nil {
 		t.Fatalf("Conversion failed: %v", err)
 	}
@@ -325,7 +360,7 @@ func TestCollectionMetadata(t *testing.T) {
 func TestInvalidInput(t *testing.T) {
 	// Test with non-existent file
 	outputFile := filepath.Join(t.TempDir(), "output.json")
-	err := convertHTTPToPostman("non-existent.http", outputFile)
+	err := convertHTTPToPostman("non-existent.http", outputFile, config{EnvName: "dev"})
 	if err == nil {
 		t.Error("Expected error for non-existent input file")
 	}
@@ -342,7 +377,7 @@ Content-Type: application/json
 	inputFile := createTempFile(t, httpContent)
 	outputFile := filepath.Join(t.TempDir(), "output.json")
 
-	err := convertHTTPToPostman(inputFile, outputFile)
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
 	if err != nil {
 		t.Fatalf("Conversion failed: %v", err)
 	}
@@ -361,6 +396,648 @@ Content-Type: application/json
 	}
 }
 
+func TestGroupedRequests(t *testing.T) {
+	httpContent := `GET https://api.example.com/health
+
+###
+
+# @group_name USERS
+# @name listUsers
+GET https://api.example.com/users
+
+###
+
+# @name getUser
+GET https://api.example.com/users/1
+
+###`
+
+	inputFile := createTempFile(t, httpContent)
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	collection := readJSONFile(t, outputFile)
+
+	if len(collection.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(collection.Items))
+	}
+
+	if collection.Items[0].Name != "request-1" {
+		t.Errorf("Expected ungrouped request first, got '%s'", collection.Items[0].Name)
+	}
+
+	folder := collection.Items[1]
+	if folder.Name != "USERS" {
+		t.Errorf("Expected folder 'USERS', got '%s'", folder.Name)
+	}
+
+	if len(folder.Item) != 2 || folder.Item[0].Name != "listUsers" || folder.Item[1].Name != "getUser" {
+		t.Errorf("Expected listUsers and getUser in folder, got %v", folder.Item)
+	}
+}
+
+func TestBodyFromFile(t *testing.T) {
+	httpContent := `POST https://api.example.com/orders
+Content-Type: application/json
+
+< ./payloads/create-order.json
+
+###`
+
+	inputFile := createTempFile(t, httpContent)
+	payloadDir := filepath.Join(filepath.Dir(inputFile), "payloads")
+	if err := os.Mkdir(payloadDir, 0755); err != nil {
+		t.Fatalf("Failed to create payload dir: %v", err)
+	}
+	payload := `{"product": "book", "quantity": 2}`
+	if err := os.WriteFile(filepath.Join(payloadDir, "create-order.json"), []byte(payload+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to create payload: %v", err)
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	// Inline mode copies the file content
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	body := readJSONFile(t, outputFile).Items[0].Request.Body
+	if body.Mode != "raw" || body.Raw != payload {
+		t.Errorf("Expected inlined raw body '%s', got %s '%s'", payload, body.Mode, body.Raw)
+	}
+
+	// Reference mode points at the file
+	err = convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev", BodyFiles: postman.BodyFileReference})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	body = readJSONFile(t, outputFile).Items[0].Request.Body
+	expectedSrc := filepath.Join(payloadDir, "create-order.json")
+	if body.Mode != "file" || body.File == nil || body.File.Src != expectedSrc {
+		t.Errorf("Expected file body '%s', got %s %v", expectedSrc, body.Mode, body.File)
+	}
+}
+
+func TestBodyFromMissingFile(t *testing.T) {
+	httpContent := `POST https://api.example.com/orders
+
+< ./missing.json
+`
+
+	inputFile := createTempFile(t, httpContent)
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
+	if err == nil || !strings.Contains(err.Error(), "missing.json") {
+		t.Errorf("Expected an error naming the missing file, got %v", err)
+	}
+}
+
+func TestScriptFiles(t *testing.T) {
+	httpContent := `< ./scripts/pre.js
+GET https://api.example.com/users
+
+> ./scripts/handler.js
+
+###`
+
+	inputFile := createTempFile(t, httpContent)
+	scriptDir := filepath.Join(filepath.Dir(inputFile), "scripts")
+	if err := os.Mkdir(scriptDir, 0755); err != nil {
+		t.Fatalf("Failed to create script dir: %v", err)
+	}
+	scripts := map[string]string{
+		"pre.js":     `request.variables.set("page", "1");`,
+		"handler.js": `client.test("ok", function() { client.assert(response.status === 200); });`,
+	}
+	for name, content := range scripts {
+		if err := os.WriteFile(filepath.Join(scriptDir, name), []byte(content+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to create script: %v", err)
+		}
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	events := readJSONFile(t, outputFile).Items[0].Event
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %+v", events)
+	}
+
+	if events[0].Listen != "prerequest" || events[0].Script.Exec[0] != `pm.variables.set("page", "1");` {
+		t.Errorf("Unexpected prerequest event %+v", events[0])
+	}
+
+	if events[1].Listen != "test" || events[1].Script.Exec[0] != `pm.test("ok", function() { pm.expect(pm.response.code === 200).to.be.true; });` {
+		t.Errorf("Unexpected test event %+v", events[1])
+	}
+
+	// A missing script file is an error
+	if err := os.Remove(filepath.Join(scriptDir, "handler.js")); err != nil {
+		t.Fatalf("Failed to remove script: %v", err)
+	}
+
+	err = convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
+	if err == nil || !strings.Contains(err.Error(), "handler.js") {
+		t.Errorf("Expected an error naming the missing script, got %v", err)
+	}
+}
+
+func TestChainedRequestVariables(t *testing.T) {
+	httpContent := `POST https://api.example.com/login
+Content-Type: application/json
+
+{"username": "admin", "password": "secret"}
+
+> {%
+    client.global.set("token", response.body.access_token);
+    client.global.set('userId', response.body.user.id);
+%}
+
+###
+
+GET https://api.example.com/users/{{userId}}
+Authorization: Bearer {{token}}
+
+###`
+
+	// No http-client.env.json: the variables come from the login response
+	inputFile := createTempFile(t, httpContent)
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	collection := readJSONFile(t, outputFile)
+
+	login := collection.Items[0]
+	if len(login.Event) != 1 || login.Event[0].Listen != "test" {
+		t.Fatalf("Expected a test event on the login request, got %+v", login.Event)
+	}
+
+	exec := strings.Join(login.Event[0].Script.Exec, "\n")
+	if !strings.Contains(exec, `pm.collectionVariables.set("token", pm.response.json().access_token);`) {
+		t.Errorf("Expected the test script to set token, got:\n%s", exec)
+	}
+
+	declared := make(map[string]bool)
+	for _, v := range collection.Variable {
+		declared[v.Key] = true
+	}
+	if !declared["token"] || !declared["userId"] {
+		t.Errorf("Expected token and userId collection variables, got %v", collection.Variable)
+	}
+}
+
+func TestChainedRequestVariablesFromHandlerFile(t *testing.T) {
+	httpContent := `POST https://api.example.com/login
+
+> ./scripts/login.js
+
+###
+
+GET https://api.example.com/users
+Authorization: Bearer {{token}}
+
+###`
+
+	// No http-client.env.json: the token comes from the handler file
+	inputFile := createTempFile(t, httpContent)
+	scriptDir := filepath.Join(filepath.Dir(inputFile), "scripts")
+	if err := os.Mkdir(scriptDir, 0755); err != nil {
+		t.Fatalf("Failed to create script dir: %v", err)
+	}
+	script := `client.global.set("token", response.body.token);`
+	if err := os.WriteFile(filepath.Join(scriptDir, "login.js"), []byte(script), 0644); err != nil {
+		t.Fatalf("Failed to create script: %v", err)
+	}
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	if err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	variables := readJSONFile(t, outputFile).Variable
+	if len(variables) != 1 || variables[0].Key != "token" {
+		t.Errorf("Expected the token collection variable, got %v", variables)
+	}
+}
+
+func TestResolveSystemVariables(t *testing.T) {
+	httpContent := `GET https://api.example.com/users
+X-Api-Key: {{$env.HTTP_TO_POSTMAN_TEST_KEY}}
+X-Secret: {{$dotenv API_SECRET}}
+
+###`
+
+	inputFile := createTempFile(t, httpContent)
+	dotEnv := filepath.Join(filepath.Dir(inputFile), ".env")
+	if err := os.WriteFile(dotEnv, []byte("API_SECRET=from-dotenv\n"), 0644); err != nil {
+		t.Fatalf("Failed to create .env: %v", err)
+	}
+	t.Setenv("HTTP_TO_POSTMAN_TEST_KEY", "from-os")
+
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev", SystemVariables: postman.SystemVariablesResolve})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	headers := readJSONFile(t, outputFile).Items[0].Request.Header
+	if headers[0].Value != "from-os" || headers[1].Value != "from-dotenv" {
+		t.Errorf("Expected resolved header values, got %v", headers)
+	}
+}
+
+func TestSelectEnvironment(t *testing.T) {
+	httpContent := `GET https://{{host}}/users
+
+###`
+
+	inputFile := createTempFile(t, httpContent)
+	envFile := filepath.Join(filepath.Dir(inputFile), "http-client.env.json")
+	envContent := `{"local": {"host": "localhost:8080"}, "staging": {"host": "staging.example.com"}}`
+	if err := os.WriteFile(envFile, []byte(envContent), 0644); err != nil {
+		t.Fatalf("Failed to create env file: %v", err)
+	}
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	if err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "staging"}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	variables := readJSONFile(t, outputFile).Variable
+	if len(variables) != 1 || variables[0].Value != "staging.example.com" {
+		t.Errorf("Expected host from the staging environment, got %v", variables)
+	}
+
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
+	if err == nil || !strings.Contains(err.Error(), "local, staging") {
+		t.Errorf("Expected an error listing the available environments, got %v", err)
+	}
+}
+
+func TestNoEnvironment(t *testing.T) {
+	httpContent := `GET https://{{host}}/users
+
+###`
+
+	// No http-client.env.json is needed when no environment is selected
+	inputFile := createTempFile(t, httpContent)
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	if err := convertHTTPToPostman(inputFile, outputFile, config{}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	variables := readJSONFile(t, outputFile).Variable
+	if len(variables) != 1 || variables[0].Key != "host" || variables[0].Value != "" {
+		t.Errorf("Expected empty variable 'host', got %v", variables)
+	}
+}
+
+func TestExportEnvironments(t *testing.T) {
+	httpContent := `@path = /users
+GET https://{{host}}{{path}}
+Authorization: Bearer {{token}}
+
+###`
+
+	inputFile := createTempFile(t, httpContent)
+	envFile := filepath.Join(filepath.Dir(inputFile), "http-client.env.json")
+	envContent := `{"local": {"host": "localhost:8080", "token": "local-token"}, "prod": {"host": "api.example.com"}}`
+	if err := os.WriteFile(envFile, []byte(envContent), 0644); err != nil {
+		t.Fatalf("Failed to create env file: %v", err)
+	}
+	outputDir := t.TempDir()
+	outputFile := filepath.Join(outputDir, "output.json")
+
+	if err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev", ExportEnvironments: true}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	// Only the file variable is left in the collection
+	variables := readJSONFile(t, outputFile).Variable
+	if len(variables) != 1 || variables[0].Key != "path" {
+		t.Errorf("Expected only the path collection variable, got %v", variables)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "local.postman_environment.json"))
+	if err != nil {
+		t.Fatalf("Failed to read environment file: %v", err)
+	}
+	var environment postman.Environment
+	if err := json.Unmarshal(data, &environment); err != nil {
+		t.Fatalf("Failed to parse environment file: %v", err)
+	}
+	if environment.Name != "local" || environment.Scope != "environment" || len(environment.Values) != 2 {
+		t.Errorf("Unexpected local environment: %+v", environment)
+	}
+
+	if _, err := os.Stat(filepath.Join(outputDir, "prod.postman_environment.json")); err != nil {
+		t.Errorf("Expected a prod environment file: %v", err)
+	}
+}
+
+func TestPrivateEnvironment(t *testing.T) {
+	httpContent := `GET https://{{host}}/users
+Authorization: Bearer {{token}}
+
+###`
+
+	// Only the private file defines the token
+	inputFile := createTempFile(t, httpContent)
+	dir := filepath.Dir(inputFile)
+	if err := os.WriteFile(filepath.Join(dir, "http-client.env.json"), []byte(`{"dev": {"host": "dev.example.com", "token": ""}}`), 0644); err != nil {
+		t.Fatalf("Failed to create env file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "http-client.private.env.json"), []byte(`{"dev": {"token": "dev-token"}}`), 0644); err != nil {
+		t.Fatalf("Failed to create private env file: %v", err)
+	}
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	if err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	variables := readJSONFile(t, outputFile).Variable
+	if len(variables) != 2 || variables[1].Value != "dev-token" || variables[1].Type != "secret" {
+		t.Errorf("Expected the private token as a secret, got %v", variables)
+	}
+
+	if err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev", BlankSecrets: true}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	variables = readJSONFile(t, outputFile).Variable
+	if variables[0].Value != "dev.example.com" || variables[1].Value != "" {
+		t.Errorf("Expected a blank secret token, got %v", variables)
+	}
+}
+
+func TestSharedEnvironment(t *testing.T) {
+	httpContent := `GET https://{{host}}/{{version}}/users
+
+###`
+
+	inputFile := createTempFile(t, httpContent)
+	envContent := `{
+  "$shared": {"version": "v2"},
+  "dev": {"host": "localhost", "SSLConfiguration": {"verifyHostCertificate": false}}
+}`
+	if err := os.WriteFile(filepath.Join(filepath.Dir(inputFile), "http-client.env.json"), []byte(envContent), 0644); err != nil {
+		t.Fatalf("Failed to create env file: %v", err)
+	}
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	if err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	values := make(map[string]string)
+	for _, v := range readJSONFile(t, outputFile).Variable {
+		values[v.Key] = v.Value
+	}
+	if values["host"] != "localhost" || values["version"] != "v2" {
+		t.Errorf("Expected host from dev and version from $shared, got %v", values)
+	}
+}
+
+func TestEnvironmentInParentDirectory(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create .git: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "http-client.env.json"), []byte(`{"dev": {"host": "root.example.com"}}`), 0644); err != nil {
+		t.Fatalf("Failed to create env file: %v", err)
+	}
+	featureDir := filepath.Join(root, "features", "users")
+	if err := os.MkdirAll(featureDir, 0755); err != nil {
+		t.Fatalf("Failed to create feature directory: %v", err)
+	}
+	inputFile := filepath.Join(featureDir, "users.http")
+	if err := os.WriteFile(inputFile, []byte("GET https://{{host}}/users\n"), 0644); err != nil {
+		t.Fatalf("Failed to create input file: %v", err)
+	}
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	if err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	variables := readJSONFile(t, outputFile).Variable
+	if len(variables) != 1 || variables[0].Value != "root.example.com" {
+		t.Errorf("Expected host from the repository root, got %v", variables)
+	}
+
+	// The search stops at the repository root
+	if err := os.Mkdir(filepath.Join(root, "features", ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create .git: %v", err)
+	}
+	if err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"}); err == nil {
+		t.Errorf("Expected an error when no environment file is found below the repository root")
+	}
+}
+
+func TestExplicitEnvironmentFiles(t *testing.T) {
+	inputFile := createTempFile(t, "GET https://{{host}}/users\nAuthorization: Bearer {{token}}\n")
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.env.json":       `{"dev": {"host": "base.example.com", "token": "base-token"}}`,
+		"override.env.json":   `{"dev": {"host": "override.example.com"}}`,
+		"my.private.env.json": `{"dev": {"token": "private-token"}}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	cfg := config{EnvName: "dev", EnvFiles: []string{
+		filepath.Join(dir, "base.env.json"),
+		filepath.Join(dir, "override.env.json"),
+		filepath.Join(dir, "my.private.env.json"),
+	}}
+	if err := convertHTTPToPostman(inputFile, outputFile, cfg); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	variables := readJSONFile(t, outputFile).Variable
+	if variables[0].Value != "override.example.com" || variables[1].Value != "private-token" || variables[1].Type != "secret" {
+		t.Errorf("Expected later files to override earlier ones, got %v", variables)
+	}
+
+	// A public file given after the private one wins, and its value is no secret
+	publicToken := filepath.Join(dir, "token.env.json")
+	if err := os.WriteFile(publicToken, []byte(`{"dev": {"token": "public-token"}}`), 0644); err != nil {
+		t.Fatalf("Failed to create token.env.json: %v", err)
+	}
+	cfg.EnvFiles = append(cfg.EnvFiles, publicToken)
+	if err := convertHTTPToPostman(inputFile, outputFile, cfg); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	variables = readJSONFile(t, outputFile).Variable
+	if variables[1].Value != "public-token" || variables[1].Type != "string" {
+		t.Errorf("Expected the last file to override the private one, got %v", variables)
+	}
+
+	cfg.EnvFiles = []string{filepath.Join(dir, "missing.env.json")}
+	inputFile = createTempFile(t, "GET https://api.example.com/users\n")
+	if err := convertHTTPToPostman(inputFile, outputFile, cfg); err == nil {
+		t.Errorf("Expected an error for a missing environment file")
+	}
+}
+
+func TestUnresolvedVariables(t *testing.T) {
+	httpContent := `GET https://api.example.com/users/{{id}}
+Authorization: Bearer {{token}}
+
+###
+
+DELETE https://api.example.com/users/{{id}}
+
+###`
+
+	// No http-client.env.json
+	inputFile := createTempFile(t, httpContent)
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
+	if err == nil || !strings.Contains(err.Error(), "{{id}} (line 1, 6), {{token}} (line 2)") {
+		t.Errorf("Expected an error listing the unresolved variables, got %v", err)
+	}
+	if _, err := os.Stat(outputFile); err == nil {
+		t.Errorf("Expected no collection in strict mode")
+	}
+
+	if err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev", Lenient: true}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	variables := readJSONFile(t, outputFile).Variable
+	if len(variables) != 2 || variables[0].Value != "" || variables[1].Value != "" {
+		t.Errorf("Expected empty id and token variables, got %v", variables)
+	}
+
+	// A present environment file only missing some variables converts in strict mode too
+	envFile := filepath.Join(filepath.Dir(inputFile), "http-client.env.json")
+	if err := os.WriteFile(envFile, []byte(`{"dev": {"id": "42"}}`), 0644); err != nil {
+		t.Fatalf("Failed to create env file: %v", err)
+	}
+	if err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	variables = readJSONFile(t, outputFile).Variable
+	if len(variables) != 2 || variables[0].Value != "42" || variables[1].Value != "" {
+		t.Errorf("Expected id from the environment and an empty token, got %v", variables)
+	}
+}
+
+func TestOAuth2FromEnvironment(t *testing.T) {
+	httpContent := `GET https://api.example.com/users
+Authorization: Bearer {{$auth.token("keycloak")}}
+
+###`
+
+	inputFile := createTempFile(t, httpContent)
+	dir := filepath.Dir(inputFile)
+	envContent := `{"dev": {"Security": {"Auth": {"keycloak": {
+  "Type": "OAuth2",
+  "Grant Type": "Client Credentials",
+  "Token URL": "https://auth.example.com/token",
+  "Client ID": "app"
+}}}}}`
+	if err := os.WriteFile(filepath.Join(dir, "http-client.env.json"), []byte(envContent), 0644); err != nil {
+		t.Fatalf("Failed to create env file: %v", err)
+	}
+	privateContent := `{"dev": {"Security": {"Auth": {"keycloak": {"Client Secret": "s3cret"}}}}}`
+	if err := os.WriteFile(filepath.Join(dir, "http-client.private.env.json"), []byte(privateContent), 0644); err != nil {
+		t.Fatalf("Failed to create private env file: %v", err)
+	}
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	clientSecret := func(cfg config) string {
+		if err := convertHTTPToPostman(inputFile, outputFile, cfg); err != nil {
+			t.Fatalf("Conversion failed: %v", err)
+		}
+		request := readJSONFile(t, outputFile).Items[0].Request
+		if request.Auth == nil || request.Auth.Type != "oauth2" || len(request.Header) != 0 {
+			t.Fatalf("Expected oauth2 auth instead of the header, got %+v", request)
+		}
+		for _, p := range request.Auth.OAuth2 {
+			if p.Key == "clientSecret" {
+				return p.Value
+			}
+		}
+		return ""
+	}
+
+	if secret := clientSecret(config{EnvName: "dev"}); secret != "s3cret" {
+		t.Errorf("Expected the client secret from the private file, got %q", secret)
+	}
+	if secret := clientSecret(config{EnvName: "dev", BlankSecrets: true}); secret != "" {
+		t.Errorf("Expected no client secret with blank secrets, got %q", secret)
+	}
+
+	// A configuration only in the private file is kept with blank secrets, without its secret
+	privateContent = `{"dev": {"Security": {"Auth": {"keycloak": {"Client Secret": "s3cret", "Password": "pa55"}, "other": {
+  "Grant Type": "Password",
+  "Token URL": "https://auth.example.com/token",
+  "Username": "admin",
+  "Password": "pa55"
+}}}}}`
+	if err := os.WriteFile(filepath.Join(dir, "http-client.private.env.json"), []byte(privateContent), 0644); err != nil {
+		t.Fatalf("Failed to create private env file: %v", err)
+	}
+	if err := os.WriteFile(inputFile, []byte(strings.ReplaceAll(httpContent, "keycloak", "other")), 0644); err != nil {
+		t.Fatalf("Failed to update input file: %v", err)
+	}
+	if err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev", BlankSecrets: true}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	params := make(map[string]string)
+	for _, p := range readJSONFile(t, outputFile).Items[0].Request.Auth.OAuth2 {
+		params[p.Key] = p.Value
+	}
+	if params["username"] != "admin" || params["accessTokenUrl"] == "" || params["password"] != "" {
+		t.Errorf("Expected the private configuration without its password, got %v", params)
+	}
+
+	// --env none keeps an empty oauth2 auth instead of failing
+	if err := convertHTTPToPostman(inputFile, outputFile, config{}); err != nil {
+		t.Fatalf("Conversion without an environment failed: %v", err)
+	}
+	if auth := readJSONFile(t, outputFile).Items[0].Request.Auth; auth == nil || auth.Type != "oauth2" {
+		t.Errorf("Expected an oauth2 auth without an environment, got %+v", auth)
+	}
+}
+
+func TestUnresolvedVariablesInBodyFilesAndContinuations(t *testing.T) {
+	httpContent := `POST https://api.example.com/orders
+    ?page={{page}}
+
+<@ ./c.json
+
+###`
+
+	inputFile := createTempFile(t, httpContent)
+	if err := os.WriteFile(filepath.Join(filepath.Dir(inputFile), "c.json"), []byte(`{"id": "{{undefinedVar}}"}`), 0644); err != nil {
+		t.Fatalf("Failed to create body file: %v", err)
+	}
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
+	if err == nil || !strings.Contains(err.Error(), "{{page}} (line 2), {{undefinedVar}} (line 4)") {
+		t.Errorf("Expected the continuation and body file variables on their lines, got %v", err)
+	}
+}
+
 // Benchmark test
 func BenchmarkConvertHTTPToPostman(b *testing.B) {
 	httpContent := `GET https://api.example.com/users
@@ -391,7 +1068,7 @@ Content-Type: application/json
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		err := convertHTTPToPostman(inputFile, outputFile)
+		err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
 		if err != nil {
 			b.Fatalf("Conversion failed: %v", err)
 		}
@@ -414,7 +1091,7 @@ Accept: application/json
 	os.WriteFile(inputFile, []byte(httpContent), 0644)
 
 	// Convert
-	err := convertHTTPToPostman(inputFile, outputFile)
+	err := convertHTTPToPostman(inputFile, outputFile, config{EnvName: "dev"})
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		return
@@ -0,0 +1,115 @@
+package httpfile
+
+// Pos is a position in a .http file. Line and Column are 1-based.
+type Pos struct {
+	Line   int
+	Column int
+}
+
+// File is the syntax tree of a whole .http file
+type File struct {
+	Blocks []*RequestBlock
+}
+
+// RequestBlock is everything between two ### separators
+type RequestBlock struct {
+	Pos        Pos
+	Separator  *Separator // The ### line that opened the block, nil for the first block
+	Comments   []*Comment
+	Directives []*Directive
+	Variables  []*VariableDecl
+	Scripts    []*Script
+	Request    *RequestLine // nil when the block contains no request
+	Headers    []*HeaderField
+	Body       *Body
+}
+
+// Separator is a ### line, Text holds anything written after the hashes
+type Separator struct {
+	Pos  Pos
+	Text string
+}
+
+// Comment is a # or // line that is not a directive
+type Comment struct {
+	Pos    Pos
+	Marker string // "#" or "//"
+	Text   string
+}
+
+// Directive is a tag comment such as # @name or # @group_name
+type Directive struct {
+	Pos   Pos
+	Name  string
+	Value string
+}
+
+// VariableDecl is a file-level variable: @name = value
+type VariableDecl struct {
+	Pos   Pos
+	Name  string
+	Value string
+}
+
+// ScriptKind tells pre-request scripts and response handlers apart
+type ScriptKind int
+
+const (
+	// PreRequestScript is a script run before the request: < {% ... %}
+	PreRequestScript ScriptKind = iota
+	// ResponseHandler is a script run on the response: > {% ... %}
+	ResponseHandler
+)
+
+// Script is a handler script block: < {% ... %} or > {% ... %}, or a reference to a
+// script file: < ./pre.js or > ./handler.js
+type Script struct {
+	Pos      Pos
+	Kind     ScriptKind
+	Source   string
+	FilePath string // Set for script file references, Source is empty then
+}
+
+// RequestLine is the method, request target and optional HTTP version of a request
+type RequestLine struct {
+	Pos     Pos
+	Method  string // Always upper case
+	Target  string
+	Version string // e.g. HTTP/1.1 or HTTP/2, empty when not given
+	// Continuations are the indented lines the target continues on, already part of Target
+	Continuations []*TargetContinuation
+}
+
+// TargetContinuation is an indented ?a=1, &b=2 or /path line continuing a request target
+type TargetContinuation struct {
+	Pos  Pos
+	Text string
+}
+
+// HeaderField is a single Name: Value header line
+type HeaderField struct {
+	Pos   Pos
+	Name  string
+	Value string
+}
+
+// Body is the request body
+type Body struct {
+	Pos Pos
+	Raw string
+	// FilePath is set when the body is loaded from a file: < ./path or <@ ./path
+	FilePath string
+	// SubstituteVariables is set for <@ file references, whose content has {{variables}} replaced
+	SubstituteVariables bool
+}
+
+// DirectiveValue returns the value of the last directive with the given name
+func (b *RequestBlock) DirectiveValue(name string) (string, bool) {
+	value, found := "", false
+	for _, d := range b.Directives {
+		if d.Name == name {
+			value, found = d.Value, true
+		}
+	}
+	return value, found
+}
@@ -0,0 +1,38 @@
+package httpfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	content := `# API credentials
+API_KEY=abc123
+export API_SECRET="s3cr3t value"
+EMPTY=
+QUOTED='single'
+not a variable
+`
+
+	values, err := ParseDotEnv(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"API_KEY":    "abc123",
+		"API_SECRET": "s3cr3t value",
+		"EMPTY":      "",
+		"QUOTED":     "single",
+	}
+
+	if len(values) != len(expected) {
+		t.Errorf("Expected %d values, got %v", len(expected), values)
+	}
+
+	for key, value := range expected {
+		if values[key] != value {
+			t.Errorf("Expected %s='%s', got '%s'", key, value, values[key])
+		}
+	}
+}
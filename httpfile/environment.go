@@ -0,0 +1,177 @@
+package httpfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Environment holds the variables of an http-client.env.json file: environment name -> variable -> value
+type Environment map[string]map[string]string
+
+// SharedEnvironment is the name of the block merged into every environment
+const SharedEnvironment = "$shared"
+
+// EnvironmentFile is a parsed http-client.env.json file
+type EnvironmentFile struct {
+	// Variables holds the string values of each environment, with $shared merged in
+	Variables Environment
+	// Sections holds the object values of each environment, such as SSLConfiguration and Security,
+	// with $shared merged in
+	Sections map[string]map[string]json.RawMessage
+	// Warnings lists the values that could not be used as variables
+	Warnings []string
+}
+
+// knownSections are the object values JetBrains gives a meaning to, they are kept without a warning
+var knownSections = map[string]bool{
+	"SSLConfiguration": true,
+	"Security":         true,
+}
+
+// ParseEnvironment reads the variables of an http-client.env.json document, see ParseEnvironmentFile
+func ParseEnvironment(r io.Reader) (Environment, error) {
+	file, err := ParseEnvironmentFile(r)
+	if err != nil {
+		return nil, err
+	}
+	return file.Variables, nil
+}
+
+// ParseEnvironmentFile reads an http-client.env.json document. Numbers and booleans become string
+// variables, objects are kept as sections, other values are skipped with a warning.
+func ParseEnvironmentFile(r io.Reader) (*EnvironmentFile, error) {
+	var raw map[string]map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	file := &EnvironmentFile{
+		Variables: make(Environment),
+		Sections:  make(map[string]map[string]json.RawMessage),
+	}
+	shared := raw[SharedEnvironment]
+	for _, name := range sortedKeys(raw) {
+		if name == SharedEnvironment {
+			continue
+		}
+
+		file.Variables[name] = make(map[string]string)
+		file.Sections[name] = make(map[string]json.RawMessage)
+		for _, values := range []map[string]json.RawMessage{shared, raw[name]} {
+			for _, key := range sortedKeys(values) {
+				file.addValue(name, key, values[key])
+			}
+		}
+	}
+	return file, nil
+}
+
+// addValue stores a single value of an environment, replacing the one taken from $shared
+func (f *EnvironmentFile) addValue(env, key string, value json.RawMessage) {
+	delete(f.Variables[env], key)
+	delete(f.Sections[env], key)
+
+	var decoded interface{}
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return
+	}
+
+	switch v := decoded.(type) {
+	case string:
+		f.Variables[env][key] = v
+	case float64, bool:
+		f.Variables[env][key] = string(value)
+	case map[string]interface{}:
+		f.Sections[env][key] = value
+		if !knownSections[key] {
+			f.Warnings = append(f.Warnings, fmt.Sprintf("environment %q: %q is an object, it is not used as a variable", env, key))
+		}
+	default:
+		f.Warnings = append(f.Warnings, fmt.Sprintf("environment %q: %q is not a string, number or boolean, skipped", env, key))
+	}
+}
+
+// sortedKeys returns the keys of a map, sorted
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MergeEnvironments returns the environments of base with the values of override taking precedence,
+// as http-client.private.env.json does over http-client.env.json
+func MergeEnvironments(base, override Environment) Environment {
+	if base == nil && override == nil {
+		return nil
+	}
+	merged := make(Environment)
+	for _, env := range []Environment{base, override} {
+		for name, values := range env {
+			if merged[name] == nil {
+				merged[name] = make(map[string]string)
+			}
+			for key, value := range values {
+				merged[name][key] = value
+			}
+		}
+	}
+	return merged
+}
+
+// Names returns the environment names, sorted
+func (e Environment) Names() []string {
+	return sortedKeys(e)
+}
+
+// AuthConfig is an authentication configuration of the Security.Auth section of an environment
+type AuthConfig struct {
+	Type              string          `json:"Type"`
+	GrantType         string          `json:"Grant Type"`
+	AuthURL           string          `json:"Auth URL"`
+	TokenURL          string          `json:"Token URL"`
+	ClientID          string          `json:"Client ID"`
+	ClientSecret      string          `json:"Client Secret"`
+	ClientCredentials string          `json:"Client Credentials"`
+	Scope             string          `json:"Scope"`
+	RedirectURL       string          `json:"Redirect URL"`
+	Username          string          `json:"Username"`
+	Password          string          `json:"Password"`
+	PKCE              json.RawMessage `json:"PKCE"`
+}
+
+// UsesPKCE reports whether the configuration enables PKCE, with true or a settings object
+func (a AuthConfig) UsesPKCE() bool {
+	pkce := string(a.PKCE)
+	return pkce != "" && pkce != "false" && pkce != "null"
+}
+
+// AuthConfigs returns the Security.Auth configurations of an environment by name. Configurations
+// found in several files are merged field by field, later files taking precedence.
+func AuthConfigs(envName string, files ...*EnvironmentFile) (map[string]AuthConfig, error) {
+	configs := make(map[string]AuthConfig)
+	for _, file := range files {
+		if file == nil || file.Sections[envName]["Security"] == nil {
+			continue
+		}
+
+		var security struct {
+			Auth map[string]json.RawMessage `json:"Auth"`
+		}
+		if err := json.Unmarshal(file.Sections[envName]["Security"], &security); err != nil {
+			return nil, fmt.Errorf("environment %q: invalid Security section: %v", envName, err)
+		}
+		for name, raw := range security.Auth {
+			config := configs[name]
+			if err := json.Unmarshal(raw, &config); err != nil {
+				return nil, fmt.Errorf("environment %q: invalid auth configuration %q: %v", envName, name, err)
+			}
+			configs[name] = config
+		}
+	}
+	return configs, nil
+}
@@ -0,0 +1,126 @@
+package httpfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEnvironment(t *testing.T) {
+	env, err := ParseEnvironment(strings.NewReader(`{"prod": {"host": "api.example.com"}, "dev": {"host": "localhost"}}`))
+	if err != nil {
+		t.Fatalf("ParseEnvironment failed: %v", err)
+	}
+
+	if names := env.Names(); len(names) != 2 || names[0] != "dev" || names[1] != "prod" {
+		t.Errorf("Expected sorted names [dev prod], got %v", names)
+	}
+	if env["prod"]["host"] != "api.example.com" {
+		t.Errorf("Expected prod host, got %q", env["prod"]["host"])
+	}
+}
+
+func TestParseEnvironmentFile(t *testing.T) {
+	content := `{
+  "$shared": {"version": "v1", "host": "shared.example.com"},
+  "dev": {
+    "host": "localhost",
+    "port": 8080,
+    "debug": true,
+    "tags": ["a", "b"],
+    "SSLConfiguration": {"clientCertificate": "cert.pem"},
+    "Security": {"Auth": {"keycloak": {"Type": "OAuth2"}}},
+    "limits": {"rate": 10}
+  },
+  "prod": {}
+}`
+
+	file, err := ParseEnvironmentFile(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseEnvironmentFile failed: %v", err)
+	}
+
+	if names := file.Variables.Names(); len(names) != 2 || names[0] != "dev" || names[1] != "prod" {
+		t.Errorf("Expected environments [dev prod] without $shared, got %v", names)
+	}
+
+	expected := map[string]string{"version": "v1", "host": "localhost", "port": "8080", "debug": "true"}
+	if len(file.Variables["dev"]) != len(expected) {
+		t.Errorf("Expected dev variables %v, got %v", expected, file.Variables["dev"])
+	}
+	for key, value := range expected {
+		if file.Variables["dev"][key] != value {
+			t.Errorf("Expected dev %s=%q, got %q", key, value, file.Variables["dev"][key])
+		}
+	}
+	if file.Variables["prod"]["host"] != "shared.example.com" {
+		t.Errorf("Expected the shared host in prod, got %v", file.Variables["prod"])
+	}
+
+	if string(file.Sections["dev"]["Security"]) != `{"Auth": {"keycloak": {"Type": "OAuth2"}}}` {
+		t.Errorf("Expected the Security section to be kept, got %s", file.Sections["dev"]["Security"])
+	}
+	if _, ok := file.Sections["dev"]["SSLConfiguration"]; !ok {
+		t.Errorf("Expected the SSLConfiguration section to be kept")
+	}
+
+	if len(file.Warnings) != 2 || !strings.Contains(file.Warnings[0], `"limits"`) || !strings.Contains(file.Warnings[1], `"tags"`) {
+		t.Errorf("Expected warnings for limits and tags, got %v", file.Warnings)
+	}
+}
+
+func TestMergeEnvironments(t *testing.T) {
+	base := Environment{"dev": {"host": "localhost", "token": "placeholder"}}
+	private := Environment{"dev": {"token": "secret"}, "prod": {"token": "prod-secret"}}
+
+	merged := MergeEnvironments(base, private)
+	if merged["dev"]["host"] != "localhost" || merged["dev"]["token"] != "secret" {
+		t.Errorf("Expected private values to take precedence, got %v", merged["dev"])
+	}
+	if merged["prod"]["token"] != "prod-secret" {
+		t.Errorf("Expected environments only in the private file, got %v", merged)
+	}
+	if base["dev"]["token"] != "placeholder" {
+		t.Errorf("Expected base to be left unchanged, got %v", base["dev"])
+	}
+
+	if MergeEnvironments(nil, nil) != nil {
+		t.Errorf("Expected nil when there are no environments")
+	}
+}
+
+func TestAuthConfigs(t *testing.T) {
+	public, err := ParseEnvironmentFile(strings.NewReader(`{"dev": {"Security": {"Auth": {"keycloak": {
+  "Type": "OAuth2",
+  "Grant Type": "Authorization Code",
+  "Token URL": "https://auth.example.com/token",
+  "Client ID": "app",
+  "PKCE": true
+}}}}}`))
+	if err != nil {
+		t.Fatalf("ParseEnvironmentFile failed: %v", err)
+	}
+	private, err := ParseEnvironmentFile(strings.NewReader(`{"dev": {"Security": {"Auth": {"keycloak": {"Client Secret": "s3cret"}}}}}`))
+	if err != nil {
+		t.Fatalf("ParseEnvironmentFile failed: %v", err)
+	}
+
+	configs, err := AuthConfigs("dev", public, nil, private)
+	if err != nil {
+		t.Fatalf("AuthConfigs failed: %v", err)
+	}
+
+	config, ok := configs["keycloak"]
+	if !ok {
+		t.Fatalf("Expected the keycloak configuration, got %v", configs)
+	}
+	if config.GrantType != "Authorization Code" || config.ClientID != "app" || config.ClientSecret != "s3cret" {
+		t.Errorf("Expected the private secret merged into the public configuration, got %+v", config)
+	}
+	if !config.UsesPKCE() {
+		t.Errorf("Expected PKCE to be enabled")
+	}
+
+	if configs, err := AuthConfigs("prod", public); err != nil || len(configs) != 0 {
+		t.Errorf("Expected no configurations for prod, got %v (%v)", configs, err)
+	}
+}
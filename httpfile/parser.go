@@ -0,0 +1,327 @@
+package httpfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// targetPattern matches a request target, which may contain spaces inside {{...}} placeholders
+const targetPattern = `(?:\{\{[^{}]*\}\}|\S)`
+
+// httpVersionPattern matches the optional HTTP version at the end of a request line
+const httpVersionPattern = `(?:\s+(HTTP/[\d.]+(?:\s+\(Prior Knowledge\))?))?$`
+
+var (
+	httpMethodRegex       = regexp.MustCompile(`(?i)^(GET|HEAD|POST|PUT|DELETE|CONNECT|OPTIONS|TRACE|PATCH|GRAPHQL)\s+(` + targetPattern + `+)` + httpVersionPattern)
+	bareTargetRegex       = regexp.MustCompile(`(?i)^((?:https?://|\{\{)` + targetPattern + `+)` + httpVersionPattern)
+	continuationRegex     = regexp.MustCompile(`(?i)^([?&/]` + targetPattern + `*)` + httpVersionPattern)
+	directiveRegex        = regexp.MustCompile(`^#\s*@([\w-]+)(?:\s+(.*))?$`)
+	descriptionRegex      = regexp.MustCompile(`^//\s*(.*)$`)
+	requestSeparatorRegex = regexp.MustCompile(`^###(.*)$`)
+	localVariableRegex    = regexp.MustCompile(`^@(\w+)\s*=\s*(.+)$`)
+	fileReferenceRegex    = regexp.MustCompile(`^<(@?)\s+(\S.*)$`)
+	scriptFileRegex       = regexp.MustCompile(`^[<>]\s+([^{\s].*)$`)
+)
+
+// Options controls how a .http file is parsed
+type Options struct {
+	// Filename is the path of the parsed file, used in error messages
+	Filename string
+}
+
+// section is the part of a request block the parser is in
+type section int
+
+const (
+	sectionPreamble section = iota // Before the request line
+	sectionHeaders                 // From the request line up to the first blank line
+	sectionBody                    // After the first blank line
+	sectionResponse                // After the body: response handlers and references
+)
+
+// parser holds the state of a single Parse call
+type parser struct {
+	file    *File
+	block   *RequestBlock
+	section section
+	script  *Script // Multi-line script being read, nil outside of scripts
+	lines   []string
+	// targetOpen is set right after a request line, while indented continuation lines may follow
+	targetOpen bool
+}
+
+// Parse parses a .http file into its syntax tree
+func Parse(r io.Reader, opts Options) (*File, error) {
+	p := &parser{file: &File{}}
+	p.startBlock(Pos{Line: 1, Column: 1}, nil)
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		p.parseLine(scanner.Text(), lineNumber)
+	}
+	if err := scanner.Err(); err != nil {
+		if opts.Filename != "" {
+			return nil, fmt.Errorf("%s: %v", opts.Filename, err)
+		}
+		return nil, err
+	}
+
+	p.endBlock()
+	return p.file, nil
+}
+
+// startBlock begins a new request block
+func (p *parser) startBlock(pos Pos, sep *Separator) {
+	p.block = &RequestBlock{Pos: pos, Separator: sep}
+	p.file.Blocks = append(p.file.Blocks, p.block)
+	p.section = sectionPreamble
+}
+
+// endBlock finishes the current request block
+func (p *parser) endBlock() {
+	if p.script != nil {
+		p.endScript()
+	}
+	p.endBody()
+}
+
+// endBody stores the body lines collected so far, without trailing blank lines
+func (p *parser) endBody() {
+	if p.section != sectionBody {
+		return
+	}
+	p.section = sectionResponse
+	if len(p.lines) == 0 {
+		return
+	}
+	p.block.Body.Raw = strings.TrimRight(strings.Join(p.lines, "\n"), " \t\r\n")
+	p.lines = nil
+
+	// Body loaded from a file: < ./payload.json
+	raw := strings.TrimSpace(p.block.Body.Raw)
+	if !strings.Contains(raw, "\n") && fileReferenceRegex.MatchString(raw) {
+		matches := fileReferenceRegex.FindStringSubmatch(raw)
+		p.block.Body.FilePath = strings.TrimSpace(matches[2])
+		p.block.Body.SubstituteVariables = matches[1] == "@"
+	}
+}
+
+// startScript begins a script block, single line scripts are stored right away
+func (p *parser) startScript(kind ScriptKind, line string, pos Pos) {
+	source := line[strings.Index(line, "{%")+2:]
+	if idx := strings.Index(source, "%}"); idx >= 0 {
+		p.block.Scripts = append(p.block.Scripts, &Script{Pos: pos, Kind: kind, Source: strings.TrimSpace(source[:idx])})
+		return
+	}
+	p.script = &Script{Pos: pos, Kind: kind}
+	p.lines = nil
+	if source = strings.TrimSpace(source); source != "" {
+		p.lines = append(p.lines, source)
+	}
+}
+
+// parseResponseHandler records a > {% ... %} or > ./handler.js line, it reports whether the line was one
+func (p *parser) parseResponseHandler(line string, pos Pos) bool {
+	switch {
+	case !strings.HasPrefix(line, ">"):
+		return false
+	case strings.Contains(line, "{%"):
+		p.startScript(ResponseHandler, line, pos)
+	case scriptFileRegex.MatchString(line):
+		p.addScriptFile(ResponseHandler, line, pos)
+	default:
+		return false
+	}
+	return true
+}
+
+// addScriptFile records a reference to a script file
+func (p *parser) addScriptFile(kind ScriptKind, line string, pos Pos) {
+	matches := scriptFileRegex.FindStringSubmatch(line)
+	p.block.Scripts = append(p.block.Scripts, &Script{Pos: pos, Kind: kind, FilePath: strings.TrimSpace(matches[1])})
+}
+
+// endScript stores the script lines collected so far
+func (p *parser) endScript() {
+	p.script.Source = strings.Join(p.lines, "\n")
+	p.block.Scripts = append(p.block.Scripts, p.script)
+	p.script = nil
+	p.lines = nil
+}
+
+func (p *parser) parseLine(rawLine string, lineNumber int) {
+	rawLine = strings.TrimRight(rawLine, "\r")
+	line := strings.TrimSpace(rawLine)
+	pos := Pos{Line: lineNumber, Column: strings.Index(rawLine, line) + 1}
+
+	if p.script != nil {
+		// Inside a multi-line script block, indentation is kept
+		if idx := strings.Index(rawLine, "%}"); idx >= 0 {
+			if rest := strings.TrimRight(rawLine[:idx], " \t"); strings.TrimSpace(rest) != "" {
+				p.lines = append(p.lines, rest)
+			}
+			p.endScript()
+			return
+		}
+		p.lines = append(p.lines, rawLine)
+		return
+	}
+
+	if requestSeparatorRegex.MatchString(line) {
+		// End of request: ### starts a new block
+		p.endBlock()
+		matches := requestSeparatorRegex.FindStringSubmatch(line)
+		p.startBlock(pos, &Separator{Pos: pos, Text: strings.TrimSpace(matches[1])})
+		return
+	}
+
+	switch p.section {
+	case sectionPreamble:
+		p.parsePreambleLine(line, pos)
+	case sectionHeaders:
+		p.parseHeaderLine(line, pos)
+	case sectionBody:
+		p.parseBodyLine(rawLine, line, pos)
+	case sectionResponse:
+		// Only response handlers are kept, response references (<> ./file) are skipped
+		p.parseResponseHandler(line, pos)
+	}
+}
+
+// parseComment records # and // lines, it reports whether the line was a comment
+func (p *parser) parseComment(line string, pos Pos) bool {
+	switch {
+	case directiveRegex.MatchString(line):
+		// Directive: # @name, # @group_name, ...
+		matches := directiveRegex.FindStringSubmatch(line)
+		p.block.Directives = append(p.block.Directives, &Directive{
+			Pos:   pos,
+			Name:  matches[1],
+			Value: strings.TrimSpace(matches[2]),
+		})
+
+	case descriptionRegex.MatchString(line):
+		matches := descriptionRegex.FindStringSubmatch(line)
+		p.block.Comments = append(p.block.Comments, &Comment{Pos: pos, Marker: "//", Text: matches[1]})
+
+	case strings.HasPrefix(line, "#"):
+		p.block.Comments = append(p.block.Comments, &Comment{Pos: pos, Marker: "#", Text: strings.TrimSpace(line[1:])})
+
+	default:
+		return false
+	}
+	return true
+}
+
+// parsePreambleLine handles lines before the request line
+func (p *parser) parsePreambleLine(line string, pos Pos) {
+	switch {
+	case line == "":
+		// Skip empty lines
+
+	case p.parseComment(line, pos):
+
+	case localVariableRegex.MatchString(line):
+		// Local variable: @var_name = value
+		matches := localVariableRegex.FindStringSubmatch(line)
+		p.block.Variables = append(p.block.Variables, &VariableDecl{
+			Pos:   pos,
+			Name:  matches[1],
+			Value: strings.TrimSpace(matches[2]),
+		})
+
+	case strings.HasPrefix(line, "<") && strings.Contains(line, "{%"):
+		// Pre-request script block (single line or multi-line)
+		p.startScript(PreRequestScript, line, pos)
+
+	case strings.HasPrefix(line, "<") && scriptFileRegex.MatchString(line):
+		// Pre-request script file: < ./pre.js
+		p.addScriptFile(PreRequestScript, line, pos)
+
+	case httpMethodRegex.MatchString(line):
+		matches := httpMethodRegex.FindStringSubmatch(line)
+		p.startRequest(&RequestLine{
+			Pos:     pos,
+			Method:  strings.ToUpper(matches[1]),
+			Target:  matches[2],
+			Version: matches[3],
+		})
+
+	case bareTargetRegex.MatchString(line):
+		// Request line without a method defaults to GET
+		matches := bareTargetRegex.FindStringSubmatch(line)
+		p.startRequest(&RequestLine{
+			Pos:     pos,
+			Method:  "GET",
+			Target:  matches[1],
+			Version: matches[2],
+		})
+	}
+}
+
+// startRequest records the request line and moves on to the headers
+func (p *parser) startRequest(request *RequestLine) {
+	p.block.Request = request
+	p.section = sectionHeaders
+	p.targetOpen = true
+}
+
+// parseHeaderLine handles lines between the request line and the first blank line
+func (p *parser) parseHeaderLine(line string, pos Pos) {
+	targetOpen := p.targetOpen
+	p.targetOpen = false
+
+	switch {
+	case line == "":
+		// The first blank line ends the headers
+		p.section = sectionBody
+
+	case p.parseResponseHandler(line, pos):
+		p.section = sectionResponse
+
+	case targetOpen && pos.Column > 1 && continuationRegex.MatchString(line):
+		// Indented continuation of the request target: ?a=1, &b=2 or /path
+		matches := continuationRegex.FindStringSubmatch(line)
+		p.block.Request.Target += matches[1]
+		p.block.Request.Continuations = append(p.block.Request.Continuations, &TargetContinuation{Pos: pos, Text: matches[1]})
+		if matches[2] != "" {
+			p.block.Request.Version = matches[2]
+		}
+		p.targetOpen = true
+
+	case p.parseComment(line, pos):
+
+	case strings.Contains(line, ":"):
+		parts := strings.SplitN(line, ":", 2)
+		p.block.Headers = append(p.block.Headers, &HeaderField{
+			Pos:   pos,
+			Name:  strings.TrimSpace(parts[0]),
+			Value: strings.TrimSpace(parts[1]),
+		})
+	}
+}
+
+// parseBodyLine handles lines after the headers, the body is kept verbatim
+func (p *parser) parseBodyLine(rawLine, line string, pos Pos) {
+	switch {
+	case p.block.Body == nil && line == "":
+		// Skip blank lines before the body
+
+	case strings.HasPrefix(line, ">") || strings.HasPrefix(line, "<>"):
+		// Response handler or response reference ends the body
+		p.endBody()
+		p.parseResponseHandler(line, pos)
+
+	case p.block.Body == nil:
+		p.block.Body = &Body{Pos: pos}
+		p.lines = []string{rawLine}
+
+	default:
+		p.lines = append(p.lines, rawLine)
+	}
+}
@@ -0,0 +1,427 @@
+package httpfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHTTPFileBlocks(t *testing.T) {
+	httpContent := `@host = api.example.com
+
+# @group_name USERS
+# @name listUsers
+// Lists all users
+GET https://{{host}}/users
+Accept: application/json
+
+### Create user
+# Creates a user
+POST https://{{host}}/users
+Content-Type: application/json
+
+{
+  "name": "Jane"
+}
+`
+
+	file, err := Parse(strings.NewReader(httpContent), Options{})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(file.Blocks) != 2 {
+		t.Fatalf("Expected 2 blocks, got %d", len(file.Blocks))
+	}
+
+	first := file.Blocks[0]
+	if first.Separator != nil {
+		t.Errorf("Expected no separator on the first block, got %v", first.Separator)
+	}
+
+	if len(first.Variables) != 1 || first.Variables[0].Name != "host" || first.Variables[0].Value != "api.example.com" {
+		t.Errorf("Expected variable host=api.example.com, got %v", first.Variables)
+	}
+
+	if len(first.Directives) != 2 {
+		t.Fatalf("Expected 2 directives, got %d", len(first.Directives))
+	}
+
+	if name, _ := first.DirectiveValue("name"); name != "listUsers" {
+		t.Errorf("Expected @name 'listUsers', got '%s'", name)
+	}
+
+	if group, _ := first.DirectiveValue("group_name"); group != "USERS" {
+		t.Errorf("Expected @group_name 'USERS', got '%s'", group)
+	}
+
+	if len(first.Comments) != 1 || first.Comments[0].Marker != "//" || first.Comments[0].Text != "Lists all users" {
+		t.Errorf("Expected one // comment, got %v", first.Comments)
+	}
+
+	if first.Request == nil {
+		t.Fatal("Expected a request line in the first block")
+	}
+
+	if first.Request.Method != "GET" || first.Request.Target != "https://{{host}}/users" {
+		t.Errorf("Unexpected request line %+v", first.Request)
+	}
+
+	if first.Request.Pos != (Pos{Line: 6, Column: 1}) {
+		t.Errorf("Expected request line at 6:1, got %v", first.Request.Pos)
+	}
+
+	if len(first.Headers) != 1 || first.Headers[0].Name != "Accept" || first.Headers[0].Value != "application/json" {
+		t.Errorf("Expected Accept header, got %v", first.Headers)
+	}
+
+	second := file.Blocks[1]
+	if second.Separator == nil || second.Separator.Text != "Create user" {
+		t.Errorf("Expected separator text 'Create user', got %v", second.Separator)
+	}
+
+	if len(second.Comments) != 1 || second.Comments[0].Marker != "#" {
+		t.Errorf("Expected one # comment, got %v", second.Comments)
+	}
+
+	if second.Body == nil {
+		t.Fatal("Expected a body in the second block")
+	}
+
+	if second.Body.Pos.Line != 14 {
+		t.Errorf("Expected body on line 14, got %d", second.Body.Pos.Line)
+	}
+
+	if !strings.HasPrefix(second.Body.Raw, "{") || !strings.HasSuffix(second.Body.Raw, "}") {
+		t.Errorf("Unexpected body '%s'", second.Body.Raw)
+	}
+}
+
+func TestParseHTTPFileScripts(t *testing.T) {
+	httpContent := `< {% request.variables.set("id", "1") %}
+< {%
+    request.variables.set("name", "john")
+%}
+GET https://api.example.com/users/{{id}}
+`
+
+	file, err := Parse(strings.NewReader(httpContent), Options{})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	block := file.Blocks[0]
+	if len(block.Scripts) != 2 {
+		t.Fatalf("Expected 2 scripts, got %d", len(block.Scripts))
+	}
+
+	if block.Scripts[0].Source != `request.variables.set("id", "1")` {
+		t.Errorf("Unexpected single-line script source '%s'", block.Scripts[0].Source)
+	}
+
+	if !strings.Contains(block.Scripts[1].Source, `request.variables.set("name", "john")`) {
+		t.Errorf("Unexpected multi-line script source '%s'", block.Scripts[1].Source)
+	}
+
+	if block.Scripts[1].Pos.Line != 2 {
+		t.Errorf("Expected multi-line script on line 2, got %d", block.Scripts[1].Pos.Line)
+	}
+
+	if block.Request == nil || block.Request.Pos.Line != 5 {
+		t.Errorf("Expected request line on line 5, got %v", block.Request)
+	}
+}
+
+func TestParseHTTPFileNestedJSONBody(t *testing.T) {
+	httpContent := `POST https://api.example.com/orders
+Content-Type: application/json
+
+{
+  "customer": {
+    "id": 1
+  },
+  "items": []
+}
+`
+
+	file, err := Parse(strings.NewReader(httpContent), Options{})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	body := file.Blocks[0].Body
+	if body == nil {
+		t.Fatal("Expected a body")
+	}
+
+	if !strings.Contains(body.Raw, `"items": []`) {
+		t.Errorf("Expected the whole nested body, got '%s'", body.Raw)
+	}
+}
+
+func TestParseHTTPFileRequestLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		method  string
+		target  string
+		version string
+	}{
+		{"PATCH https://api.example.com/users/1", "PATCH", "https://api.example.com/users/1", ""},
+		{"head https://api.example.com/", "HEAD", "https://api.example.com/", ""},
+		{"TRACE https://api.example.com/ HTTP/1.1", "TRACE", "https://api.example.com/", "HTTP/1.1"},
+		{"CONNECT proxy.example.com:443 HTTP/2", "CONNECT", "proxy.example.com:443", "HTTP/2"},
+		{"GET https://api.example.com/ HTTP/2 (Prior Knowledge)", "GET", "https://api.example.com/", "HTTP/2 (Prior Knowledge)"},
+		{"graphql https://api.example.com/graphql", "GRAPHQL", "https://api.example.com/graphql", ""},
+		{"https://api.example.com/users", "GET", "https://api.example.com/users", ""},
+		{"{{baseUrl}}/users HTTP/1.1", "GET", "{{baseUrl}}/users", "HTTP/1.1"},
+		{"GET /users HTTP/1.1", "GET", "/users", "HTTP/1.1"},
+	}
+
+	for _, tt := range tests {
+		file, err := Parse(strings.NewReader(tt.line+"\n"), Options{})
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+
+		request := file.Blocks[0].Request
+		if request == nil {
+			t.Errorf("Expected a request for '%s'", tt.line)
+			continue
+		}
+
+		if request.Method != tt.method || request.Target != tt.target || request.Version != tt.version {
+			t.Errorf("Expected %s %s %s for '%s', got %+v", tt.method, tt.target, tt.version, tt.line, request)
+		}
+	}
+}
+
+func TestParseHTTPFileMultiLineTarget(t *testing.T) {
+	httpContent := `GET https://api.example.com
+    /users
+    ?page=1
+    &limit=10 HTTP/1.1
+Accept: application/json
+`
+
+	file, err := Parse(strings.NewReader(httpContent), Options{})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	block := file.Blocks[0]
+	if block.Request.Target != "https://api.example.com/users?page=1&limit=10" {
+		t.Errorf("Expected joined target, got '%s'", block.Request.Target)
+	}
+
+	if block.Request.Version != "HTTP/1.1" {
+		t.Errorf("Expected version HTTP/1.1, got '%s'", block.Request.Version)
+	}
+
+	if len(block.Headers) != 1 || block.Headers[0].Name != "Accept" {
+		t.Errorf("Expected only the Accept header, got %v", block.Headers)
+	}
+}
+
+func TestParseHTTPFileBodyBoundary(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		headers int
+		body    string
+	}{
+		{
+			name:    "json array",
+			content: "POST https://api.example.com/users\nContent-Type: application/json\n\n[\n  {\"id\": 1}\n]\n",
+			headers: 1,
+			body:    "[\n  {\"id\": 1}\n]",
+		},
+		{
+			name:    "first body line with a colon",
+			content: "POST https://api.example.com/notes\nContent-Type: text/plain\n\nNote: remember this\n\n  indented line\n\n###\n",
+			headers: 1,
+			body:    "Note: remember this\n\n  indented line",
+		},
+		{
+			name:    "xml",
+			content: "POST https://api.example.com/soap\nContent-Type: text/xml\n\n<?xml version=\"1.0\"?>\n<Envelope>\n  <Body/>\n</Envelope>\n",
+			headers: 1,
+			body:    "<?xml version=\"1.0\"?>\n<Envelope>\n  <Body/>\n</Envelope>",
+		},
+		{
+			name:    "body ends at response handler",
+			content: "POST https://api.example.com/users\n\n# not a comment\n> {% client.test(\"ok\", function() {}); %}\n",
+			headers: 0,
+			body:    "# not a comment",
+		},
+		{
+			name:    "no body",
+			content: "GET https://api.example.com/users\nAccept: */*\n\n\n###\n",
+			headers: 1,
+			body:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		file, err := Parse(strings.NewReader(tt.content), Options{})
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", tt.name, err)
+		}
+
+		block := file.Blocks[0]
+		if len(block.Headers) != tt.headers {
+			t.Errorf("%s: expected %d headers, got %v", tt.name, tt.headers, block.Headers)
+		}
+
+		body := ""
+		if block.Body != nil {
+			body = block.Body.Raw
+		}
+		if body != tt.body {
+			t.Errorf("%s: expected body %q, got %q", tt.name, tt.body, body)
+		}
+	}
+}
+
+func TestParseHTTPFileBodyFromFile(t *testing.T) {
+	tests := []struct {
+		content    string
+		path       string
+		substitute bool
+	}{
+		{"POST https://api.example.com/orders\n\n< ./payloads/create-order.json\n", "./payloads/create-order.json", false},
+		{"POST https://api.example.com/orders\n\n<@ ./payloads/template.json\n", "./payloads/template.json", true},
+		{"POST https://api.example.com/orders\n\n<order/>\n", "", false},
+	}
+
+	for _, tt := range tests {
+		file, err := Parse(strings.NewReader(tt.content), Options{})
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+
+		body := file.Blocks[0].Body
+		if body == nil {
+			t.Fatalf("Expected a body for %q", tt.content)
+		}
+
+		if body.FilePath != tt.path || body.SubstituteVariables != tt.substitute {
+			t.Errorf("Expected file %q (substitute %v), got %q (%v)", tt.path, tt.substitute, body.FilePath, body.SubstituteVariables)
+		}
+	}
+}
+
+func TestParseHTTPFileResponseHandler(t *testing.T) {
+	httpContent := `POST https://api.example.com/users
+Content-Type: application/json
+
+{"name": "Jane"}
+
+> {%
+    client.test("created", function() {
+        client.assert(response.status === 201);
+    });
+%}
+
+<> 2024-01-01T120000.200.json
+`
+
+	file, err := Parse(strings.NewReader(httpContent), Options{})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	block := file.Blocks[0]
+	if block.Body == nil || block.Body.Raw != `{"name": "Jane"}` {
+		t.Errorf("Expected the body to end before the handler, got %v", block.Body)
+	}
+
+	if len(block.Scripts) != 1 || block.Scripts[0].Kind != ResponseHandler {
+		t.Fatalf("Expected one response handler, got %v", block.Scripts)
+	}
+
+	expected := "    client.test(\"created\", function() {\n        client.assert(response.status === 201);\n    });"
+	if block.Scripts[0].Source != expected {
+		t.Errorf("Expected handler source %q, got %q", expected, block.Scripts[0].Source)
+	}
+}
+
+func TestParseHTTPFileScriptFiles(t *testing.T) {
+	httpContent := `< ./scripts/pre.js
+POST https://api.example.com/users
+
+< ./payload.json
+
+> ./scripts/handler.js
+`
+
+	file, err := Parse(strings.NewReader(httpContent), Options{})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	block := file.Blocks[0]
+	if len(block.Scripts) != 2 {
+		t.Fatalf("Expected 2 scripts, got %v", block.Scripts)
+	}
+
+	if block.Scripts[0].Kind != PreRequestScript || block.Scripts[0].FilePath != "./scripts/pre.js" {
+		t.Errorf("Expected pre-request script file, got %+v", block.Scripts[0])
+	}
+
+	if block.Scripts[1].Kind != ResponseHandler || block.Scripts[1].FilePath != "./scripts/handler.js" {
+		t.Errorf("Expected response handler file, got %+v", block.Scripts[1])
+	}
+
+	if block.Body == nil || block.Body.FilePath != "./payload.json" {
+		t.Errorf("Expected body file ./payload.json, got %v", block.Body)
+	}
+}
+
+func TestProducedVariables(t *testing.T) {
+	httpContent := `POST https://api.example.com/login
+
+> {%
+    client.global.set("token", response.body.token);
+    client.global.set('refresh', response.body.refresh);
+    request.variables.set("local", "1");
+%}
+`
+
+	file, err := Parse(strings.NewReader(httpContent), Options{})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	produced := file.ProducedVariables()
+	if strings.Join(produced, ",") != "token,refresh" {
+		t.Errorf("Expected token and refresh, got %v", produced)
+	}
+}
+
+func TestVariableUses(t *testing.T) {
+	httpContent := `@base = https://{{host}}
+
+GET {{base}}/users
+    ?page={{page}}
+Authorization: Bearer {{token}}
+
+{
+  "owner": "{{user}}"
+}
+`
+
+	file, err := Parse(strings.NewReader(httpContent), Options{})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected := []VariableUse{{"host", 1}, {"base", 3}, {"page", 4}, {"token", 5}, {"user", 8}}
+	uses := file.VariableUses()
+	if len(uses) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, uses)
+	}
+	for i, use := range uses {
+		if use != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], use)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package httpfile
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	variableRegex  = regexp.MustCompile(`\{\{(\w+)\}\}`)
+	globalSetRegex = regexp.MustCompile(`client\.global\.set\(\s*["'](\w+)["']`)
+)
+
+// DetectVariables finds all variables in the format {{variableName}} in the text
+func DetectVariables(text string) []string {
+	matches := variableRegex.FindAllStringSubmatch(text, -1)
+	var variables []string
+	for _, match := range matches {
+		if len(match) > 1 {
+			variables = append(variables, match[1])
+		}
+	}
+	return variables
+}
+
+// VariableUse is a {{variable}} reference and the line it is on
+type VariableUse struct {
+	Name string
+	Line int
+}
+
+// UsedVariables returns every {{variable}} referenced by the file, in order of appearance
+func (f *File) UsedVariables() []string {
+	var variables []string
+	for _, use := range f.VariableUses() {
+		variables = append(variables, use.Name)
+	}
+	return variables
+}
+
+// VariableUses returns every {{variable}} reference of the file with its line, in order of appearance.
+// References inside scripts are reported on the line the script starts.
+func (f *File) VariableUses() []VariableUse {
+	var uses []VariableUse
+	add := func(text string, line int) {
+		for _, name := range DetectVariables(text) {
+			uses = append(uses, VariableUse{Name: name, Line: line})
+		}
+	}
+
+	for _, block := range f.Blocks {
+		for _, v := range block.Variables {
+			add(v.Value, v.Pos.Line)
+		}
+		for _, script := range block.Scripts {
+			add(script.Source, script.Pos.Line)
+		}
+		if request := block.Request; request != nil {
+			first := len(request.Target)
+			for _, c := range request.Continuations {
+				first -= len(c.Text)
+			}
+			add(request.Target[:first], request.Pos.Line)
+			for _, c := range request.Continuations {
+				add(c.Text, c.Pos.Line)
+			}
+		}
+		for _, h := range block.Headers {
+			add(h.Name+": "+h.Value, h.Pos.Line)
+		}
+		if block.Body != nil {
+			for i, line := range strings.Split(block.Body.Raw, "\n") {
+				add(line, block.Body.Pos.Line+i)
+			}
+		}
+	}
+	return uses
+}
+
+// GlobalVariables returns the names of the variables a script stores with client.global.set
+func GlobalVariables(source string) []string {
+	var variables []string
+	for _, match := range globalSetRegex.FindAllStringSubmatch(source, -1) {
+		variables = append(variables, match[1])
+	}
+	return variables
+}
+
+// ProducedVariables returns the variables the inline scripts of the file store with
+// client.global.set, so later requests can use them without an environment. Script files
+// are not read, postman.Report lists the variables of both.
+func (f *File) ProducedVariables() []string {
+	var variables []string
+	for _, block := range f.Blocks {
+		for _, script := range block.Scripts {
+			variables = append(variables, GlobalVariables(script.Source)...)
+		}
+	}
+	return variables
+}
@@ -0,0 +1,188 @@
+package postman
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/FrantPRO/jetbrains-http-to-postman/httpfile"
+)
+
+// authTokenRegex matches an Authorization header taking its token from an env file auth configuration:
+// Bearer {{$auth.token("name")}}
+var authTokenRegex = regexp.MustCompile(`^(?:Bearer\s+)?\{\{\$auth\.(?:token|idToken)\(\s*["']([^"']+)["']\s*\)\}\}$`)
+
+// oauth2GrantTypes maps JetBrains grant types to Postman ones
+var oauth2GrantTypes = map[string]string{
+	"Authorization Code": "authorization_code",
+	"Client Credentials": "client_credentials",
+	"Password":           "password_credentials",
+	"Implicit":           "implicit",
+}
+
+// convertAuth converts an Authorization header into a Postman auth, it returns nil for other headers
+// and for credentials Postman auth can't hold
+func (c *converter) convertAuth(h *httpfile.HeaderField) (*Auth, error) {
+	if !strings.EqualFold(h.Name, "Authorization") {
+		return nil, nil
+	}
+
+	if matches := authTokenRegex.FindStringSubmatch(h.Value); matches != nil {
+		config, ok := c.auth[matches[1]]
+		if !ok && c.envName == "" {
+			c.warnings = append(c.warnings, fmt.Sprintf("line %d: no environment, auth configuration %q is converted with empty settings", h.Pos.Line, matches[1]))
+			return &Auth{Type: "oauth2", OAuth2: []AuthParam{
+				{Key: "tokenName", Value: matches[1], Type: "string"},
+				{Key: "addTokenTo", Value: "header", Type: "string"},
+			}}, nil
+		}
+		if !ok {
+			return nil, fmt.Errorf("line %d: auth configuration %q not found in the Security.Auth section of the environment", h.Pos.Line, matches[1])
+		}
+		if c.blankSecrets {
+			config.ClientSecret = ""
+			config.Password = ""
+		}
+		auth, err := oauth2Auth(matches[1], config)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", h.Pos.Line, err)
+		}
+		return auth, nil
+	}
+
+	scheme, credentials, _ := strings.Cut(h.Value, " ")
+	credentials = strings.TrimSpace(credentials)
+	if credentials == "" {
+		return nil, nil
+	}
+
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		return &Auth{Type: "bearer", Bearer: []AuthParam{
+			{Key: "token", Value: c.replaceVariables(credentials), Type: "string"},
+		}}, nil
+
+	case "basic":
+		username, password, ok := basicCredentials(credentials)
+		if !ok {
+			return nil, nil
+		}
+		return &Auth{Type: "basic", Basic: []AuthParam{
+			{Key: "username", Value: c.replaceVariables(username), Type: "string"},
+			{Key: "password", Value: c.replaceVariables(password), Type: "string"},
+		}}, nil
+
+	case "digest":
+		// JetBrains form: Digest username password
+		fields := strings.Fields(credentials)
+		if len(fields) != 2 || strings.Contains(credentials, "=") {
+			return nil, nil
+		}
+		return &Auth{Type: "digest", Digest: []AuthParam{
+			{Key: "username", Value: c.replaceVariables(fields[0]), Type: "string"},
+			{Key: "password", Value: c.replaceVariables(fields[1]), Type: "string"},
+		}}, nil
+	}
+	return nil, nil
+}
+
+// basicCredentials returns the username and password of Basic credentials, either encoded as
+// base64(username:password) or unencoded as "username password"
+func basicCredentials(credentials string) (string, string, bool) {
+	fields := strings.Fields(credentials)
+	switch len(fields) {
+	case 1:
+		decoded, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return "", "", false
+		}
+		return strings.Cut(string(decoded), ":")
+	case 2:
+		return fields[0], fields[1], true
+	}
+	return "", "", false
+}
+
+// oauth2Auth converts an env file auth configuration into a Postman oauth2 auth
+func oauth2Auth(name string, config httpfile.AuthConfig) (*Auth, error) {
+	if config.Type != "" && !strings.EqualFold(config.Type, "OAuth2") {
+		return nil, fmt.Errorf("auth configuration %q: unsupported type %q", name, config.Type)
+	}
+
+	grantType, ok := oauth2GrantTypes[config.GrantType]
+	if !ok {
+		return nil, fmt.Errorf("auth configuration %q: grant type %q is not supported by Postman", name, config.GrantType)
+	}
+	if grantType == "authorization_code" && config.UsesPKCE() {
+		grantType = "authorization_code_with_pkce"
+	}
+
+	clientAuthentication := ""
+	switch strings.ToLower(config.ClientCredentials) {
+	case "":
+	case "in body", "none":
+		clientAuthentication = "body"
+	default:
+		clientAuthentication = "header"
+	}
+
+	auth := &Auth{Type: "oauth2"}
+	for _, param := range []struct{ key, value string }{
+		{"tokenName", name},
+		{"grant_type", grantType},
+		{"authUrl", config.AuthURL},
+		{"accessTokenUrl", config.TokenURL},
+		{"clientId", config.ClientID},
+		{"clientSecret", config.ClientSecret},
+		{"client_authentication", clientAuthentication},
+		{"scope", config.Scope},
+		{"redirect_uri", config.RedirectURL},
+		{"username", config.Username},
+		{"password", config.Password},
+		{"addTokenTo", "header"},
+	} {
+		if param.value != "" {
+			auth.OAuth2 = append(auth.OAuth2, AuthParam{Key: param.key, Value: param.value, Type: "string"})
+		}
+	}
+	return auth, nil
+}
+
+// hoistAuth moves the auth shared by all items up to their parent, requests of a folder to the folder
+// first. It returns the auth of the parent, nil when the items don't share one.
+func hoistAuth(items []Item) *Auth {
+	for i := range items {
+		if len(items[i].Item) > 0 {
+			items[i].Auth = hoistAuth(items[i].Item)
+		}
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+	shared := itemAuth(&items[0])
+	if shared == nil {
+		return nil
+	}
+	for i := range items[1:] {
+		if !reflect.DeepEqual(itemAuth(&items[i+1]), shared) {
+			return nil
+		}
+	}
+
+	for i := range items {
+		items[i].Auth = nil
+		items[i].Request.Auth = nil
+	}
+	return shared
+}
+
+// itemAuth returns the auth of a folder or a request
+func itemAuth(item *Item) *Auth {
+	if len(item.Item) > 0 {
+		return item.Auth
+	}
+	return item.Request.Auth
+}
@@ -0,0 +1,230 @@
+package postman
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/FrantPRO/jetbrains-http-to-postman/httpfile"
+)
+
+// authParams returns the parameters of an auth as a map
+func authParams(params []AuthParam) map[string]string {
+	values := make(map[string]string)
+	for _, p := range params {
+		values[p.Key] = p.Value
+	}
+	return values
+}
+
+func TestConvertOAuth2(t *testing.T) {
+	httpContent := `GET https://api.example.com/users
+Authorization: Bearer {{$auth.token("keycloak")}}
+Accept: application/json
+`
+
+	auth := map[string]httpfile.AuthConfig{
+		"keycloak": {
+			Type:              "OAuth2",
+			GrantType:         "Client Credentials",
+			TokenURL:          "https://auth.example.com/token",
+			ClientID:          "app",
+			ClientSecret:      "{{clientSecret}}",
+			ClientCredentials: "in body",
+			Scope:             "openid profile",
+		},
+	}
+
+	request := convertString(t, httpContent, Options{Auth: auth}).Items[0].Request
+	if len(request.Header) != 1 || request.Header[0].Key != "Accept" {
+		t.Errorf("Expected the Authorization header to be removed, got %v", request.Header)
+	}
+	if request.Auth == nil || request.Auth.Type != "oauth2" {
+		t.Fatalf("Expected oauth2 auth, got %+v", request.Auth)
+	}
+
+	expected := map[string]string{
+		"tokenName":             "keycloak",
+		"grant_type":            "client_credentials",
+		"accessTokenUrl":        "https://auth.example.com/token",
+		"clientId":              "app",
+		"clientSecret":          "{{clientSecret}}",
+		"client_authentication": "body",
+		"scope":                 "openid profile",
+		"addTokenTo":            "header",
+	}
+	params := authParams(request.Auth.OAuth2)
+	if len(params) != len(expected) {
+		t.Errorf("Expected %v, got %v", expected, params)
+	}
+	for key, value := range expected {
+		if params[key] != value {
+			t.Errorf("Expected %s=%q, got %q", key, value, params[key])
+		}
+	}
+}
+
+func TestConvertAuthorizationHeaders(t *testing.T) {
+	tests := []struct {
+		header   string
+		authType string
+		expected map[string]string
+	}{
+		{"Bearer {{token}}", "bearer", map[string]string{"token": "{{token}}"}},
+		{"Basic dXNlcjpwQHNz", "basic", map[string]string{"username": "user", "password": "p@ss"}},
+		{"Basic {{user}} {{password}}", "basic", map[string]string{"username": "{{user}}", "password": "{{password}}"}},
+		{"Digest admin secret", "digest", map[string]string{"username": "admin", "password": "secret"}},
+	}
+
+	for _, tt := range tests {
+		request := convertString(t, "GET https://api.example.com\nAuthorization: "+tt.header+"\n", Options{}).Items[0].Request
+		if len(request.Header) != 0 {
+			t.Errorf("%s: expected the header to be removed, got %v", tt.header, request.Header)
+		}
+		if request.Auth == nil || request.Auth.Type != tt.authType {
+			t.Errorf("%s: expected %s auth, got %+v", tt.header, tt.authType, request.Auth)
+			continue
+		}
+
+		params := authParams(append(append(request.Auth.Bearer, request.Auth.Basic...), request.Auth.Digest...))
+		for key, value := range tt.expected {
+			if params[key] != value {
+				t.Errorf("%s: expected %s=%q, got %q", tt.header, key, value, params[key])
+			}
+		}
+	}
+}
+
+func TestConvertUnsupportedAuthorizationHeaders(t *testing.T) {
+	// Kept as headers: Postman auth can't hold them
+	for _, header := range []string{
+		"Basic {{credentials}}",
+		`Digest username="admin", realm="api", nonce="abc"`,
+		"ApiKey 12345",
+	} {
+		request := convertString(t, "GET https://api.example.com\nAuthorization: "+header+"\n", Options{}).Items[0].Request
+		if request.Auth != nil || len(request.Header) != 1 || request.Header[0].Value != header {
+			t.Errorf("Expected %q to stay a header, got auth %+v and headers %v", header, request.Auth, request.Header)
+		}
+	}
+}
+
+func TestOAuth2GrantTypes(t *testing.T) {
+	tests := []struct {
+		config   httpfile.AuthConfig
+		expected string
+	}{
+		{httpfile.AuthConfig{GrantType: "Authorization Code"}, "authorization_code"},
+		{httpfile.AuthConfig{GrantType: "Authorization Code", PKCE: []byte("true")}, "authorization_code_with_pkce"},
+		{httpfile.AuthConfig{GrantType: "Password"}, "password_credentials"},
+		{httpfile.AuthConfig{GrantType: "Implicit"}, "implicit"},
+	}
+
+	for _, tt := range tests {
+		auth, err := oauth2Auth("test", tt.config)
+		if err != nil {
+			t.Fatalf("oauth2Auth failed: %v", err)
+		}
+		if grantType := authParams(auth.OAuth2)["grant_type"]; grantType != tt.expected {
+			t.Errorf("Expected grant type %s for %q, got %s", tt.expected, tt.config.GrantType, grantType)
+		}
+	}
+
+	if _, err := oauth2Auth("test", httpfile.AuthConfig{GrantType: "Device Authorization"}); err == nil {
+		t.Errorf("Expected an error for a grant type Postman doesn't support")
+	}
+}
+
+func TestConvertUnknownAuthConfig(t *testing.T) {
+	file, err := httpfile.Parse(strings.NewReader("GET https://api.example.com\nAuthorization: Bearer {{$auth.token(\"missing\")}}\n"), httpfile.Options{})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	_, err = Convert(file, Options{EnvName: "dev"})
+	if err == nil || !strings.Contains(err.Error(), `line 2: auth configuration "missing"`) {
+		t.Errorf("Expected an error naming the missing configuration, got %v", err)
+	}
+
+	// Without an environment the auth is kept with empty settings
+	var report Report
+	collection, err := Convert(file, Options{Report: &report})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	auth := collection.Items[0].Request.Auth
+	if auth == nil || auth.Type != "oauth2" || authParams(auth.OAuth2)["tokenName"] != "missing" {
+		t.Errorf("Expected an empty oauth2 auth, got %+v", auth)
+	}
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], `"missing"`) {
+		t.Errorf("Expected a warning about the missing configuration, got %v", report.Warnings)
+	}
+}
+
+func TestHoistAuth(t *testing.T) {
+	httpContent := `# @group_name users
+GET https://api.example.com/users
+Authorization: Bearer {{$auth.token("main")}}
+
+###
+GET https://api.example.com/users/1
+Authorization: Bearer {{$auth.token("main")}}
+
+###
+# @group_name admin
+GET https://api.example.com/admin
+Authorization: Bearer {{$auth.token("admin")}}
+`
+
+	auth := map[string]httpfile.AuthConfig{
+		"main":  {GrantType: "Client Credentials", ClientID: "main"},
+		"admin": {GrantType: "Client Credentials", ClientID: "admin"},
+	}
+
+	collection := convertString(t, httpContent, Options{Auth: auth, HoistAuth: true})
+	if collection.Auth != nil {
+		t.Errorf("Expected no collection auth for different folder auth, got %+v", collection.Auth)
+	}
+
+	users := collection.Items[0]
+	if users.Auth == nil || authParams(users.Auth.OAuth2)["clientId"] != "main" {
+		t.Errorf("Expected the users folder to get the main auth, got %+v", users.Auth)
+	}
+	for _, item := range users.Item {
+		if item.Request.Auth != nil {
+			t.Errorf("Expected requests to inherit the folder auth, got %+v", item.Request.Auth)
+		}
+	}
+
+	// The same auth everywhere ends up on the collection
+	auth["admin"] = auth["main"]
+	collection = convertString(t, strings.ReplaceAll(httpContent, `"admin"`, `"main"`), Options{Auth: auth, HoistAuth: true})
+	if collection.Auth == nil || collection.Items[0].Auth != nil || collection.Items[1].Auth != nil {
+		t.Errorf("Expected the auth on the collection only, got %+v", collection)
+	}
+}
+
+func TestHoistHeaderAuth(t *testing.T) {
+	httpContent := `GET https://api.example.com/users
+Authorization: Bearer {{token}}
+
+###
+DELETE https://api.example.com/users/1
+Authorization: Bearer {{token}}
+`
+
+	collection := convertString(t, httpContent, Options{HoistAuth: true})
+	if collection.Auth == nil || collection.Auth.Type != "bearer" {
+		t.Fatalf("Expected bearer auth on the collection, got %+v", collection.Auth)
+	}
+	for _, item := range collection.Items {
+		if item.Request.Auth != nil {
+			t.Errorf("Expected %s to inherit the collection auth, got %+v", item.Request.Method, item.Request.Auth)
+		}
+	}
+
+	// Without the option every request keeps its own auth
+	collection = convertString(t, httpContent, Options{})
+	if collection.Auth != nil || collection.Items[1].Request.Auth == nil {
+		t.Errorf("Expected request auth only, got %+v", collection)
+	}
+}
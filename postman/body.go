@@ -0,0 +1,276 @@
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/FrantPRO/jetbrains-http-to-postman/httpfile"
+)
+
+var (
+	// placeholderRegex matches any {{...}} placeholder
+	placeholderRegex = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+	// filePartRegex matches a "< ./path" multipart part, the form the parser uses for body files
+	filePartRegex = regexp.MustCompile(`^<\s+(\S.*)$`)
+)
+
+// convertBody converts the body of a request block into a Postman body
+func (c *converter) convertBody(block *httpfile.RequestBlock) (Body, error) {
+	if block.Body == nil {
+		return Body{}, nil
+	}
+
+	raw := block.Body.Raw
+	if block.Body.FilePath != "" {
+		path := c.resolvePath(block.Body.FilePath)
+		if c.bodyFiles == BodyFileReference {
+			return Body{Mode: "file", File: &BodyFile{Src: path}}, nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Body{}, fmt.Errorf("line %d: failed to read body file: %v", block.Body.Pos.Line, err)
+		}
+		raw = strings.TrimRight(string(data), "\r\n")
+		if !block.Body.SubstituteVariables && placeholderRegex.MatchString(raw) {
+			// Postman substitutes {{...}} in every raw body, a file body is the only way to send
+			// a < file as-is
+			return Body{Mode: "file", File: &BodyFile{Src: path}}, nil
+		}
+		if block.Body.SubstituteVariables {
+			for _, name := range httpfile.DetectVariables(raw) {
+				c.fileVariables = append(c.fileVariables, httpfile.VariableUse{Name: name, Line: block.Body.Pos.Line})
+			}
+		}
+	}
+
+	if block.Body.FilePath == "" || block.Body.SubstituteVariables {
+		raw = c.replaceVariables(raw)
+	}
+
+	contentType, _ := headerValue(block, "Content-Type")
+	if isGraphQL(block) {
+		query, variables := splitGraphQL(raw)
+		return Body{
+			Mode:    "graphql",
+			GraphQL: &GraphQLBody{Query: query, Variables: variables},
+		}, nil
+	}
+
+	switch mediaType(contentType) {
+	case "application/x-www-form-urlencoded":
+		return Body{
+			Mode:       "urlencoded",
+			URLEncoded: parseURLEncoded(raw),
+		}, nil
+	case "multipart/form-data":
+		if boundary := mediaTypeParam(contentType, "boundary"); boundary != "" {
+			formData := parseMultipart(raw, boundary)
+			for i := range formData {
+				if formData[i].Type == "file" {
+					formData[i].Src = c.resolvePath(formData[i].Src)
+				}
+			}
+			return Body{
+				Mode:     "formdata",
+				FormData: formData,
+			}, nil
+		}
+	}
+
+	return Body{
+		Mode: "raw",
+		Raw:  raw,
+		Options: map[string]interface{}{
+			"raw": map[string]interface{}{
+				"language": rawLanguage(contentType, raw),
+			},
+		},
+	}, nil
+}
+
+// isGraphQL reports whether the block is a GraphQL request: GRAPHQL method or application/graphql content
+func isGraphQL(block *httpfile.RequestBlock) bool {
+	contentType, _ := headerValue(block, "Content-Type")
+	return block.Request.Method == "GRAPHQL" || mediaType(contentType) == "application/graphql"
+}
+
+// splitGraphQL splits a GraphQL body into the query document and the optional JSON variables
+// object that follows it after a blank line
+func splitGraphQL(raw string) (string, string) {
+	lines := strings.Split(raw, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i-1]) != "" || !strings.HasPrefix(strings.TrimSpace(lines[i]), "{") {
+			continue
+		}
+
+		variables := strings.TrimSpace(strings.Join(lines[i:], "\n"))
+		// {{variables}} outside of strings would make the JSON invalid
+		if json.Valid([]byte(placeholderRegex.ReplaceAllString(variables, "0"))) {
+			return strings.TrimSpace(strings.Join(lines[:i], "\n")), variables
+		}
+	}
+	return strings.TrimSpace(raw), ""
+}
+
+// resolvePath resolves a file reference relative to the .http file
+func (c *converter) resolvePath(path string) string {
+	if c.baseDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.baseDir, path)
+}
+
+// headerValue returns the value of the first header with the given name, ignoring case
+func headerValue(block *httpfile.RequestBlock, name string) (string, bool) {
+	for _, h := range block.Headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value, true
+		}
+	}
+	return "", false
+}
+
+// mediaType returns the lower-cased media type of a Content-Type value, without parameters
+func mediaType(contentType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+// mediaTypeParam returns a parameter of a Content-Type value such as boundary=..., without quotes
+func mediaTypeParam(contentType, name string) string {
+	for _, param := range strings.Split(contentType, ";")[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(key, name) {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
+
+// parseMultipart splits a multipart/form-data body into its parts. A part whose content is
+// a "< ./path" line becomes a file part referencing that path.
+func parseMultipart(raw, boundary string) []FormDataParam {
+	var params []FormDataParam
+	var part *FormDataParam
+	var content []string
+	inHeaders := false
+
+	endPart := func() {
+		if part == nil {
+			return
+		}
+		value := strings.TrimRight(strings.Join(content, "\n"), "\r\n")
+		if matches := filePartRegex.FindStringSubmatch(strings.TrimSpace(value)); matches != nil && !strings.Contains(value, "\n") {
+			part.Type = "file"
+			part.Src = strings.TrimSpace(matches[1])
+		} else {
+			part.Type = "text"
+			part.Value = value
+		}
+		params = append(params, *part)
+		part = nil
+	}
+
+	for _, line := range strings.Split(raw, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "--"+boundary+"--":
+			endPart()
+			return params
+
+		case trimmed == "--"+boundary:
+			endPart()
+			part = &FormDataParam{}
+			content = nil
+			inHeaders = true
+
+		case part == nil:
+			// Preamble before the first boundary
+
+		case inHeaders && trimmed == "":
+			inHeaders = false
+
+		case inHeaders:
+			name, value, _ := strings.Cut(trimmed, ":")
+			switch {
+			case strings.EqualFold(strings.TrimSpace(name), "Content-Disposition"):
+				part.Key = mediaTypeParam(value, "name")
+			case strings.EqualFold(strings.TrimSpace(name), "Content-Type"):
+				part.ContentType = strings.TrimSpace(value)
+			}
+
+		default:
+			content = append(content, line)
+		}
+	}
+
+	endPart()
+	return params
+}
+
+// parseURLEncoded splits a form body into its fields. The body may be spread over several
+// lines, each starting with &. Values are decoded, {{variables}} are left untouched.
+func parseURLEncoded(raw string) []URLEncodedParam {
+	var joined strings.Builder
+	for _, line := range strings.Split(raw, "\n") {
+		joined.WriteString(strings.TrimSpace(line))
+	}
+
+	var params []URLEncodedParam
+	for _, pair := range strings.Split(joined.String(), "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		params = append(params, URLEncodedParam{
+			Key:   decodeFormValue(key),
+			Value: decodeFormValue(value),
+			Type:  "text",
+		})
+	}
+	return params
+}
+
+// decodeFormValue decodes a form-encoded value, returning it unchanged when it isn't valid
+func decodeFormValue(value string) string {
+	decoded, err := url.QueryUnescape(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// rawLanguage picks the Postman raw body language from the Content-Type header,
+// falling back to looking at the body itself
+func rawLanguage(contentType, raw string) string {
+	media := mediaType(contentType)
+	switch {
+	case strings.Contains(media, "json"):
+		return "json"
+	case strings.Contains(media, "html"):
+		return "html"
+	case strings.Contains(media, "xml"):
+		return "xml"
+	case strings.Contains(media, "javascript"), strings.Contains(media, "ecmascript"):
+		return "javascript"
+	case strings.HasPrefix(media, "text/"):
+		return "text"
+	}
+
+	// Content sniffing
+	content := strings.ToLower(strings.TrimSpace(raw))
+	switch {
+	case strings.HasPrefix(content, "{"), strings.HasPrefix(content, "["):
+		return "json"
+	case strings.HasPrefix(content, "<!doctype html"), strings.HasPrefix(content, "<html"):
+		return "html"
+	case strings.HasPrefix(content, "<"):
+		return "xml"
+	}
+	return "text"
+}
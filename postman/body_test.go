@@ -0,0 +1,262 @@
+package postman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRawLanguage(t *testing.T) {
+	tests := []struct {
+		contentType string
+		raw         string
+		expected    string
+	}{
+		{"application/json", `{"a": 1}`, "json"},
+		{"application/vnd.api+json; charset=utf-8", `{"a": 1}`, "json"},
+		{"text/xml; charset=utf-8", "<Envelope/>", "xml"},
+		{"application/soap+xml", "<Envelope/>", "xml"},
+		{"text/html", "<p>hi</p>", "html"},
+		{"application/javascript", "console.log(1)", "javascript"},
+		{"text/plain", `{"a": 1}`, "text"},
+		{"", `[1, 2]`, "json"},
+		{"", "<!DOCTYPE html><html></html>", "html"},
+		{"", `<?xml version="1.0"?><a/>`, "xml"},
+		{"application/octet-stream", "hello", "text"},
+	}
+
+	for _, tt := range tests {
+		if actual := rawLanguage(tt.contentType, tt.raw); actual != tt.expected {
+			t.Errorf("rawLanguage(%q, %q): expected '%s', got '%s'", tt.contentType, tt.raw, tt.expected, actual)
+		}
+	}
+}
+
+func TestConvertSOAPBody(t *testing.T) {
+	httpContent := `POST https://api.example.com/soap
+Content-Type: text/xml; charset=utf-8
+SOAPAction: "GetUser"
+
+<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body/>
+</soap:Envelope>
+`
+
+	collection := convertString(t, httpContent, Options{})
+
+	body := collection.Items[0].Request.Body
+	if body.Mode != "raw" {
+		t.Errorf("Expected body mode 'raw', got '%s'", body.Mode)
+	}
+
+	language := body.Options["raw"].(map[string]interface{})["language"]
+	if language != "xml" {
+		t.Errorf("Expected raw language 'xml', got '%v'", language)
+	}
+
+	expected := "<?xml version=\"1.0\"?>\n<soap:Envelope xmlns:soap=\"http://schemas.xmlsoap.org/soap/envelope/\">\n  <soap:Body/>\n</soap:Envelope>"
+	if body.Raw != expected {
+		t.Errorf("Expected body %q, got %q", expected, body.Raw)
+	}
+}
+
+func TestConvertURLEncodedBody(t *testing.T) {
+	httpContent := `POST https://api.example.com/login
+Content-Type: application/x-www-form-urlencoded
+
+username={{user}}
+&password=p%40ss+word
+&remember=
+`
+
+	collection := convertString(t, httpContent, Options{})
+
+	body := collection.Items[0].Request.Body
+	if body.Mode != "urlencoded" {
+		t.Fatalf("Expected body mode 'urlencoded', got '%s'", body.Mode)
+	}
+
+	if body.Raw != "" {
+		t.Errorf("Expected no raw body, got '%s'", body.Raw)
+	}
+
+	expected := []URLEncodedParam{
+		{Key: "username", Value: "{{user}}", Type: "text"},
+		{Key: "password", Value: "p@ss word", Type: "text"},
+		{Key: "remember", Value: "", Type: "text"},
+	}
+
+	if len(body.URLEncoded) != len(expected) {
+		t.Fatalf("Expected %d fields, got %v", len(expected), body.URLEncoded)
+	}
+
+	for i, e := range expected {
+		if body.URLEncoded[i] != e {
+			t.Errorf("Expected field %v, got %v", e, body.URLEncoded[i])
+		}
+	}
+}
+
+func TestConvertMultipartBody(t *testing.T) {
+	httpContent := `POST https://api.example.com/upload
+Content-Type: multipart/form-data; boundary="WebAppBoundary"
+Accept: application/json
+
+--WebAppBoundary
+Content-Disposition: form-data; name="description"
+
+Holiday photo
+of {{user}}
+--WebAppBoundary
+Content-Disposition: form-data; name="photo"; filename="photo.png"
+Content-Type: image/png
+
+< ./images/photo.png
+--WebAppBoundary
+Content-Disposition: form-data; name="meta"
+Content-Type: application/json
+
+{"tags": ["beach"]}
+--WebAppBoundary--
+`
+
+	collection := convertString(t, httpContent, Options{})
+
+	request := collection.Items[0].Request
+	if request.Body.Mode != "formdata" {
+		t.Fatalf("Expected body mode 'formdata', got '%s'", request.Body.Mode)
+	}
+
+	expected := []FormDataParam{
+		{Key: "description", Value: "Holiday photo\nof {{user}}", Type: "text"},
+		{Key: "photo", Src: "./images/photo.png", Type: "file", ContentType: "image/png"},
+		{Key: "meta", Value: `{"tags": ["beach"]}`, Type: "text", ContentType: "application/json"},
+	}
+
+	if len(request.Body.FormData) != len(expected) {
+		t.Fatalf("Expected %d parts, got %v", len(expected), request.Body.FormData)
+	}
+
+	for i, e := range expected {
+		if request.Body.FormData[i] != e {
+			t.Errorf("Expected part %+v, got %+v", e, request.Body.FormData[i])
+		}
+	}
+
+	if len(request.Header) != 1 || request.Header[0].Key != "Accept" {
+		t.Errorf("Expected the multipart Content-Type header to be dropped, got %v", request.Header)
+	}
+}
+
+func TestConvertMultipartMarkupPart(t *testing.T) {
+	httpContent := `POST https://api.example.com/upload
+Content-Type: multipart/form-data; boundary=boundary
+
+--boundary
+Content-Disposition: form-data; name="note"
+Content-Type: application/xml
+
+<note>hi</note>
+--boundary
+Content-Disposition: form-data; name="page"
+Content-Type: text/html
+
+<p>Hello</p>
+--boundary--
+`
+
+	parts := convertString(t, httpContent, Options{}).Items[0].Request.Body.FormData
+	expected := []FormDataParam{
+		{Key: "note", Value: "<note>hi</note>", Type: "text", ContentType: "application/xml"},
+		{Key: "page", Value: "<p>Hello</p>", Type: "text", ContentType: "text/html"},
+	}
+	if len(parts) != len(expected) {
+		t.Fatalf("Expected %d parts, got %v", len(expected), parts)
+	}
+	for i, e := range expected {
+		if parts[i] != e {
+			t.Errorf("Expected part %+v, got %+v", e, parts[i])
+		}
+	}
+}
+
+func TestConvertGraphQLBody(t *testing.T) {
+	httpContent := `GRAPHQL https://api.example.com/graphql
+Authorization: Bearer {{token}}
+
+query User($id: ID!) {
+  user(id: $id) {
+    name
+
+    email
+  }
+}
+
+{
+  "id": {{userId}}
+}
+
+###
+
+POST https://api.example.com/graphql
+Content-Type: application/graphql
+
+{
+  users { name }
+}
+`
+
+	collection := convertString(t, httpContent, Options{})
+
+	if len(collection.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(collection.Items))
+	}
+
+	first := collection.Items[0].Request
+	if first.Method != "POST" {
+		t.Errorf("Expected GRAPHQL to be sent as POST, got '%s'", first.Method)
+	}
+
+	if first.Body.Mode != "graphql" || first.Body.GraphQL == nil {
+		t.Fatalf("Expected body mode 'graphql', got %+v", first.Body)
+	}
+
+	expectedQuery := "query User($id: ID!) {\n  user(id: $id) {\n    name\n\n    email\n  }\n}"
+	if first.Body.GraphQL.Query != expectedQuery {
+		t.Errorf("Expected query %q, got %q", expectedQuery, first.Body.GraphQL.Query)
+	}
+
+	expectedVariables := "{\n  \"id\": {{userId}}\n}"
+	if first.Body.GraphQL.Variables != expectedVariables {
+		t.Errorf("Expected variables %q, got %q", expectedVariables, first.Body.GraphQL.Variables)
+	}
+
+	second := collection.Items[1].Request
+	if second.Body.Mode != "graphql" || second.Body.GraphQL.Query != "{\n  users { name }\n}" || second.Body.GraphQL.Variables != "" {
+		t.Errorf("Expected an anonymous query without variables, got %+v", second.Body.GraphQL)
+	}
+
+	if len(second.Header) != 0 {
+		t.Errorf("Expected the application/graphql Content-Type to be dropped, got %v", second.Header)
+	}
+}
+
+func TestConvertBodyFileSubstitution(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "payload.json"), []byte(`{"id": "{{$uuid}}"}`), 0644); err != nil {
+		t.Fatalf("Failed to create payload: %v", err)
+	}
+
+	// < sends the file as-is, which only a Postman file body does once it holds placeholders
+	body := convertString(t, "POST https://api.example.com\n\n< ./payload.json\n", Options{BaseDir: dir}).Items[0].Request.Body
+	if body.Mode != "file" || body.File == nil || body.File.Src != filepath.Join(dir, "payload.json") {
+		t.Errorf("Expected a file body for <, got %+v", body)
+	}
+
+	// <@ substitutes the variables
+	body = convertString(t, "POST https://api.example.com\n\n<@ ./payload.json\n", Options{BaseDir: dir}).Items[0].Request.Body
+	if body.Mode != "raw" || body.Raw != `{"id": "{{$guid}}"}` {
+		t.Errorf("Expected a raw body with translated variables for <@, got %+v", body)
+	}
+}
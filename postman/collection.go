@@ -0,0 +1,154 @@
+package postman
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Schema is the Postman collection format written by this package
+const Schema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// Collection is a Postman v2.1 collection
+type Collection struct {
+	Info     Info       `json:"info"`
+	Items    []Item     `json:"item"`
+	Variable []Variable `json:"variable"`
+	Event    []Event    `json:"event,omitempty"`
+	Auth     *Auth      `json:"auth,omitempty"`
+}
+
+// Info holds the collection metadata
+type Info struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// Item is a request or, when Item is set, a folder of requests
+type Item struct {
+	Name                    string                 `json:"name"`
+	Description             string                 `json:"description,omitempty"`
+	Item                    []Item                 `json:"item,omitempty"`
+	Request                 Request                `json:"request,omitempty"`
+	Event                   []Event                `json:"event,omitempty"`
+	Auth                    *Auth                  `json:"auth,omitempty"`
+	ProtocolProfileBehavior map[string]interface{} `json:"protocolProfileBehavior,omitempty"`
+}
+
+// Event is a script run by Postman, Listen is "prerequest" or "test"
+type Event struct {
+	Listen string `json:"listen"`
+	Script Script `json:"script"`
+}
+
+// Script is the JavaScript source of an event, one line per Exec entry
+type Script struct {
+	Type string   `json:"type"`
+	Exec []string `json:"exec"`
+}
+
+// Request is a single Postman request
+type Request struct {
+	Method string   `json:"method"`
+	Header []Header `json:"header"`
+	Body   Body     `json:"body"`
+	URL    URL      `json:"url"`
+	Auth   *Auth    `json:"auth,omitempty"`
+}
+
+// Auth is the authentication of a request, folder or collection, the parameters are stored under the
+// field named after Type. Items without Auth inherit the one of their parent.
+type Auth struct {
+	Type   string      `json:"type"`
+	Bearer []AuthParam `json:"bearer,omitempty"`
+	Basic  []AuthParam `json:"basic,omitempty"`
+	Digest []AuthParam `json:"digest,omitempty"`
+	OAuth2 []AuthParam `json:"oauth2,omitempty"`
+}
+
+// AuthParam is a single setting of an Auth
+type AuthParam struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// Header is a request header
+type Header struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// Body is a request body
+type Body struct {
+	Mode       string                 `json:"mode,omitempty"`
+	Raw        string                 `json:"raw,omitempty"`
+	URLEncoded []URLEncodedParam      `json:"urlencoded,omitempty"`
+	FormData   []FormDataParam        `json:"formdata,omitempty"`
+	File       *BodyFile              `json:"file,omitempty"`
+	GraphQL    *GraphQLBody           `json:"graphql,omitempty"`
+	Options    map[string]interface{} `json:"options,omitempty"`
+}
+
+// URLEncodedParam is a single field of an application/x-www-form-urlencoded body
+type URLEncodedParam struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// BodyFile points a file body at a file on disk
+type BodyFile struct {
+	Src string `json:"src"`
+}
+
+// GraphQLBody is the query and the JSON encoded variables of a GraphQL request
+type GraphQLBody struct {
+	Query     string `json:"query"`
+	Variables string `json:"variables,omitempty"`
+}
+
+// FormDataParam is a single part of a multipart/form-data body, either text or a file
+type FormDataParam struct {
+	Key         string `json:"key"`
+	Value       string `json:"value,omitempty"`
+	Src         string `json:"src,omitempty"`
+	Type        string `json:"type"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// URL is a request URL split into its Postman parts
+type URL struct {
+	Raw      string       `json:"raw"`
+	Protocol string       `json:"protocol,omitempty"`
+	Host     []string     `json:"host,omitempty"`
+	Port     string       `json:"port,omitempty"`
+	Path     []string     `json:"path,omitempty"`
+	Query    []QueryParam `json:"query,omitempty"`
+	Variable []Variable   `json:"variable,omitempty"`
+}
+
+// QueryParam is a single query string parameter
+type QueryParam struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Variable is a collection or path variable
+type Variable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+// WriteCollection writes the collection to w as indented JSON
+func WriteCollection(w io.Writer, collection Collection) error {
+	output, err := json.MarshalIndent(collection, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(output)
+	return err
+}
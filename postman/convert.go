@@ -0,0 +1,535 @@
+package postman
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/FrantPRO/jetbrains-http-to-postman/httpfile"
+)
+
+// Options controls how a .http file is converted
+type Options struct {
+	// Environment supplies the values of {{variables}}, it may be nil
+	Environment httpfile.Environment
+	// PrivateEnvironment holds the values of http-client.private.env.json, they take precedence
+	// over Environment and are marked as secrets
+	PrivateEnvironment httpfile.Environment
+	// BlankSecrets leaves the values of PrivateEnvironment, and the client secrets and passwords of
+	// Auth, out so they don't leak into shared files
+	BlankSecrets bool
+	// EnvName selects the environment used from Environment, empty means variables get no values
+	EnvName string
+	// BaseDir is the directory relative file references are resolved against,
+	// normally the directory of the .http file
+	BaseDir string
+	// BodyFiles selects how "< ./file" bodies are converted
+	BodyFiles BodyFileMode
+	// SystemVariables selects how {{$env.NAME}}, {{$processEnv.NAME}} and {{$dotenv NAME}} are converted
+	SystemVariables SystemVariableMode
+	// ProcessEnv supplies the values of $env and $processEnv variables in SystemVariablesResolve mode
+	ProcessEnv map[string]string
+	// DotEnv supplies the values of $dotenv variables in SystemVariablesResolve mode
+	DotEnv map[string]string
+	// Auth holds the Security.Auth configurations of the environment, {{$auth.token("name")}}
+	// Authorization headers are converted into Postman oauth2 auth with them
+	Auth map[string]httpfile.AuthConfig
+	// HoistAuth moves auth shared by all requests of a folder to the folder, and auth shared by all
+	// items to the collection
+	HoistAuth bool
+	// Report, when set, is filled in with what the conversion found out about the file
+	Report *Report
+	// ExportEnvironments leaves the variables defined in Environment out of the collection,
+	// their values come from the Postman environments built with Environments
+	ExportEnvironments bool
+}
+
+// Report holds what a conversion found out about the file beyond the collection itself
+type Report struct {
+	// ProducedVariables are the variables set with client.global.set, by inline scripts and
+	// script files alike
+	ProducedVariables []string
+	// FileVariables are the variables used in inlined <@ body files, reported on the <@ line
+	FileVariables []httpfile.VariableUse
+	// Warnings lists what was converted with missing information
+	Warnings []string
+}
+
+// BodyFileMode selects how bodies loaded from files are converted
+type BodyFileMode int
+
+const (
+	// BodyFileInline copies the file content into a raw body
+	BodyFileInline BodyFileMode = iota
+	// BodyFileReference emits a Postman file body pointing at the file
+	BodyFileReference
+)
+
+// SystemVariableMode selects how variables read from the OS environment or a .env file are converted
+type SystemVariableMode int
+
+const (
+	// SystemVariablesPlaceholder turns them into collection variables with empty values
+	SystemVariablesPlaceholder SystemVariableMode = iota
+	// SystemVariablesResolve replaces them with their values from ProcessEnv and DotEnv
+	SystemVariablesResolve
+)
+
+// converter holds the state of a single Convert call
+type converter struct {
+	env            httpfile.Environment // Environment merged with PrivateEnvironment
+	private        httpfile.Environment
+	blankSecrets   bool
+	envName        string
+	baseDir        string
+	bodyFiles      BodyFileMode
+	systemMode     SystemVariableMode
+	processEnv     map[string]string
+	dotEnv         map[string]string
+	exportEnvs     bool
+	auth           map[string]httpfile.AuthConfig
+	warnings       []string
+	localVariables map[string]string
+	fileVariables  []httpfile.VariableUse // Variables used in inlined <@ body files, on the <@ line
+	// producedVariables are set by scripts with client.global.set
+	producedVariables []string
+	// systemVariables are the $env, $processEnv and $dotenv variables turned into collection variables
+	systemVariables []string
+	// dynamic translates dynamic variables of the request being converted
+	dynamic *dynamicTranslator
+	// collectionDynamic translates dynamic variables of @name = value declarations
+	collectionDynamic *dynamicTranslator
+	count             int
+}
+
+// Convert converts a parsed .http file into a Postman collection
+func Convert(file *httpfile.File, opts Options) (Collection, error) {
+	env := httpfile.MergeEnvironments(opts.Environment, opts.PrivateEnvironment)
+	if env != nil && opts.EnvName != "" && !opts.ExportEnvironments {
+		if _, ok := env[opts.EnvName]; !ok {
+			return Collection{}, fmt.Errorf("environment %q not found, available environments: %s",
+				opts.EnvName, strings.Join(env.Names(), ", "))
+		}
+	}
+
+	c := &converter{
+		env:            env,
+		private:        opts.PrivateEnvironment,
+		blankSecrets:   opts.BlankSecrets,
+		envName:        opts.EnvName,
+		baseDir:        opts.BaseDir,
+		bodyFiles:      opts.BodyFiles,
+		systemMode:     opts.SystemVariables,
+		processEnv:     opts.ProcessEnv,
+		dotEnv:         opts.DotEnv,
+		exportEnvs:     opts.ExportEnvironments,
+		auth:           opts.Auth,
+		localVariables: make(map[string]string),
+		// Distinct names keep request snippets from overwriting the collection ones
+		collectionDynamic: &dynamicTranslator{prefix: "collection_"},
+	}
+
+	var items []Item
+	folder := -1 // Index of the current group folder in items, -1 when outside a group
+	for _, block := range file.Blocks {
+		for _, v := range block.Variables {
+			c.localVariables[v.Name] = c.replaceLocalVariable(v.Value)
+		}
+
+		if groupName, ok := block.DirectiveValue("group_name"); ok {
+			// Group definition: # @group_name PRODUCTS
+			items = append(items, Item{Name: groupName})
+			folder = len(items) - 1
+		}
+
+		if block.Request == nil {
+			continue
+		}
+
+		item, err := c.convertBlock(block)
+		if err != nil {
+			return Collection{}, err
+		}
+		if folder >= 0 {
+			items[folder].Item = append(items[folder].Item, item)
+		} else {
+			items = append(items, item)
+		}
+	}
+
+	// Drop groups that ended up without requests
+	var nonEmpty []Item
+	for _, item := range items {
+		if item.Request.Method != "" || len(item.Item) > 0 {
+			nonEmpty = append(nonEmpty, item)
+		}
+	}
+
+	if opts.Report != nil {
+		opts.Report.ProducedVariables = c.producedVariables
+		opts.Report.FileVariables = c.fileVariables
+		opts.Report.Warnings = c.warnings
+	}
+
+	var auth *Auth
+	if opts.HoistAuth {
+		auth = hoistAuth(nonEmpty)
+	}
+
+	var collectionEvents []Event
+	if snippets := c.collectionDynamic.snippets; len(snippets) > 0 {
+		// Values for dynamic variables of file variables without a Postman equivalent
+		collectionEvents = append(collectionEvents, Event{
+			Listen: "prerequest",
+			Script: Script{Type: "text/javascript", Exec: snippets},
+		})
+	}
+
+	today := time.Now().Format("20060102150405")
+	return Collection{
+		Info: Info{
+			Name:   fmt.Sprintf("jb-export-%s", today),
+			Schema: Schema,
+		},
+		Items:    nonEmpty,
+		Variable: c.collectionVariables(file),
+		Event:    collectionEvents,
+		Auth:     auth,
+	}, nil
+}
+
+// convertBlock converts a single request block into a Postman item
+func (c *converter) convertBlock(block *httpfile.RequestBlock) (Item, error) {
+	c.dynamic = &dynamicTranslator{}
+
+	target, hostHeader := requestTarget(block)
+	target = c.replaceVariables(target)
+	url := URL{Raw: target}
+	parseURL(target, &url, c.localVariables)
+	url.Query = parseQuery(target)
+
+	body, err := c.convertBody(block)
+	if err != nil {
+		return Item{}, err
+	}
+
+	headers := []Header{}
+	var auth *Auth
+	for _, h := range block.Headers {
+		if h == hostHeader {
+			// Already part of the URL
+			continue
+		}
+		headerAuth, err := c.convertAuth(h)
+		if err != nil {
+			return Item{}, err
+		}
+		if headerAuth != nil {
+			auth = headerAuth
+			continue
+		}
+		if (body.Mode == "formdata" || body.Mode == "graphql") && strings.EqualFold(h.Name, "Content-Type") {
+			// Postman sets the content type itself: its own multipart boundary, JSON for GraphQL
+			continue
+		}
+		headers = append(headers, Header{Key: h.Name, Value: c.replaceVariables(h.Value), Type: "text"})
+	}
+
+	method := block.Request.Method
+	if method == "GRAPHQL" {
+		// GraphQL requests are sent as POST
+		method = "POST"
+	}
+
+	item := Item{
+		Request: Request{
+			Method: method,
+			Header: headers,
+			Body:   body,
+			URL:    url,
+			Auth:   auth,
+		},
+	}
+
+	events := []struct {
+		listen string
+		kind   httpfile.ScriptKind
+	}{
+		{"prerequest", httpfile.PreRequestScript},
+		{"test", httpfile.ResponseHandler},
+	}
+	for _, e := range events {
+		exec, err := c.translateScripts(block, e.kind)
+		if err != nil {
+			return Item{}, err
+		}
+		if e.kind == httpfile.PreRequestScript {
+			// Values for dynamic variables without a Postman equivalent
+			exec = append(c.dynamic.snippets, exec...)
+		}
+		if len(exec) > 0 {
+			item.Event = append(item.Event, Event{
+				Listen: e.listen,
+				Script: Script{Type: "text/javascript", Exec: exec},
+			})
+		}
+	}
+
+	if version := protocolVersion(block.Request.Version); version != "" {
+		item.ProtocolProfileBehavior = map[string]interface{}{
+			"protocolVersion": version,
+		}
+	}
+
+	// Set name and description
+	if name, ok := block.DirectiveValue("name"); ok && name != "" {
+		item.Name = name
+	} else {
+		c.count++
+		item.Name = fmt.Sprintf("request-%d", c.count)
+	}
+
+	for _, comment := range block.Comments {
+		if comment.Marker == "//" && comment.Text != "" {
+			item.Description = comment.Text
+		}
+	}
+
+	return item, nil
+}
+
+// requestTarget returns the absolute URL of the request. Origin-form targets (/path) are combined
+// with the Host header, which is returned so it isn't copied as a plain header.
+func requestTarget(block *httpfile.RequestBlock) (string, *httpfile.HeaderField) {
+	target := block.Request.Target
+	if !strings.HasPrefix(target, "/") {
+		return target, nil
+	}
+
+	for _, h := range block.Headers {
+		if strings.EqualFold(h.Name, "Host") && h.Value != "" {
+			if host, ok := strings.CutSuffix(h.Value, ":443"); ok {
+				return "https://" + host + target, h
+			}
+			return "http://" + h.Value + target, h
+		}
+	}
+	return target, nil
+}
+
+// protocolVersion maps an HTTP version from the request line to Postman's protocolVersion setting
+func protocolVersion(version string) string {
+	version = strings.ToUpper(version)
+	switch {
+	case strings.HasPrefix(version, "HTTP/1"):
+		return "http1"
+	case strings.HasPrefix(version, "HTTP/2"):
+		return "http2"
+	}
+	return ""
+}
+
+// inEnvironment reports whether any environment defines the variable
+func (c *converter) inEnvironment(name string) bool {
+	for _, values := range c.env {
+		if _, ok := values[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecret reports whether the value of the variable comes from the private environment
+func (c *converter) isSecret(envName, name string) bool {
+	_, ok := c.private[envName][name]
+	return ok
+}
+
+// environmentValue returns the value and the Postman variable type of an environment value,
+// secrets are blanked when blank is set
+func environmentValue(value string, secret, blank bool) (string, string) {
+	if !secret {
+		return value, "string"
+	}
+	if blank {
+		return "", "secret"
+	}
+	return value, "secret"
+}
+
+// collectionVariables builds the collection variables from the variables used in the file
+func (c *converter) collectionVariables(file *httpfile.File) []Variable {
+	var collectionVariables []Variable
+	uniqueVars := make(map[string]bool)
+
+	// Add detected variables from file content, then the ones set by scripts
+	// and the ones standing in for system variables
+	variables := file.UsedVariables()
+	for _, use := range c.fileVariables {
+		variables = append(variables, use.Name)
+	}
+	variables = append(variables, c.producedVariables...)
+	for _, varName := range append(variables, c.systemVariables...) {
+		if uniqueVars[varName] {
+			continue
+		}
+		uniqueVars[varName] = true
+		value := ""
+		varType := "string"
+
+		// Check local variables first
+		if localValue, exists := c.localVariables[varName]; exists {
+			value = localValue
+		} else if c.exportEnvs && c.inEnvironment(varName) {
+			// Defined by the exported environments
+			continue
+		} else if val, exists := c.env[c.envName][varName]; exists {
+			// Then check environment variables
+			value, varType = environmentValue(val, c.isSecret(c.envName, varName), c.blankSecrets)
+		}
+
+		collectionVariables = append(collectionVariables, Variable{
+			Key:   varName,
+			Value: value,
+			Type:  varType,
+		})
+	}
+
+	// Add any local variables that weren't detected in the content
+	for _, block := range file.Blocks {
+		for _, v := range block.Variables {
+			if uniqueVars[v.Name] {
+				continue
+			}
+			uniqueVars[v.Name] = true
+			collectionVariables = append(collectionVariables, Variable{
+				Key:   v.Name,
+				Value: c.localVariables[v.Name],
+				Type:  "string",
+			})
+		}
+	}
+
+	// Note: Request-level variables are set by the prerequest event of each request and are
+	// not added to the collection variables
+
+	return collectionVariables
+}
+
+// parseQuery extracts the query parameters from a raw URL
+func parseQuery(rawURL string) []QueryParam {
+	query := []QueryParam{}
+	if !strings.Contains(rawURL, "?") {
+		return query
+	}
+
+	queryString := strings.SplitN(rawURL, "?", 2)[1]
+	for _, param := range strings.Split(queryString, "&") {
+		if strings.Contains(param, "=") {
+			kv := strings.SplitN(param, "=", 2)
+			query = append(query, QueryParam{
+				Key:   strings.TrimSpace(kv[0]),
+				Value: strings.TrimSpace(kv[1]),
+			})
+		}
+	}
+	return query
+}
+
+// parseURL parses a URL and sets the appropriate fields for Postman format
+func parseURL(rawURL string, url *URL, localVars map[string]string) {
+	var pathVariables []Variable
+	varRegex := regexp.MustCompile(`\{\{(\w+)\}\}`)
+
+	// Handle variables in URL by keeping them as-is
+	if strings.Contains(rawURL, "{{baseUrl}}") || strings.Contains(rawURL, "{{baseURL}}") {
+		// For URLs with baseUrl variable, set host to the variable
+		url.Host = []string{"{{baseUrl}}"}
+		// Parse the path part - split by / and remove query params
+		pathPart := rawURL
+		if strings.Contains(pathPart, "?") {
+			pathPart = strings.Split(pathPart, "?")[0]
+		}
+
+		if strings.Contains(pathPart, "/") {
+			parts := strings.Split(pathPart, "/")
+			if len(parts) > 1 {
+				var pathParts []string
+				for i, part := range parts {
+					if i == 0 {
+						continue // Skip the baseUrl part
+					}
+					if part != "" {
+						// Check if this path segment contains variables
+						if varRegex.MatchString(part) {
+							convertedPart := part
+							matches := varRegex.FindAllStringSubmatch(part, -1)
+							for _, match := range matches {
+								if len(match) > 1 {
+									varName := match[1]
+									// Convert {{variable}} to :variable for path
+									convertedPart = strings.ReplaceAll(convertedPart, match[0], ":"+varName)
+
+									// Local variables are known now, anything else (environment,
+									// pre-request scripts) is resolved by Postman when the request runs
+									varValue := "{{" + varName + "}}"
+									if val, exists := localVars[varName]; exists {
+										varValue = val
+									}
+
+									// Add to path variables
+									pathVariables = append(pathVariables, Variable{
+										Key:   varName,
+										Value: varValue,
+										Type:  "string",
+									})
+								}
+							}
+							pathParts = append(pathParts, convertedPart)
+						} else {
+							pathParts = append(pathParts, part)
+						}
+					}
+				}
+				url.Path = pathParts
+			}
+		}
+
+		if len(pathVariables) > 0 {
+			url.Variable = pathVariables
+		}
+		return
+	}
+
+	// Standard URL parsing
+	if strings.Contains(rawURL, "://") {
+		protocolParts := strings.Split(rawURL, "://")
+		url.Protocol = protocolParts[0]
+
+		cleanURL := protocolParts[1]
+		if strings.Contains(cleanURL, "?") {
+			cleanURL = strings.Split(cleanURL, "?")[0]
+		}
+
+		urlParts := strings.Split(cleanURL, "/")
+		host := urlParts[0]
+		if idx := strings.LastIndex(host, ":"); idx >= 0 && !strings.Contains(host[idx:], "}") {
+			url.Port = host[idx+1:]
+			host = host[:idx]
+		}
+		url.Host = strings.Split(host, ".")
+		if len(urlParts) > 1 {
+			url.Path = urlParts[1:]
+		}
+	} else if strings.HasPrefix(rawURL, "/") {
+		// Origin-form target without a Host header: path only
+		cleanURL := strings.Split(rawURL, "?")[0]
+		url.Path = strings.Split(strings.TrimPrefix(cleanURL, "/"), "/")
+	} else {
+		urlParts := strings.Split(rawURL, "/")
+		if len(urlParts) > 0 {
+			url.Host = strings.Split(urlParts[0], ".")
+		}
+	}
+}
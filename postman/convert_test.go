@@ -0,0 +1,215 @@
+package postman
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/FrantPRO/jetbrains-http-to-postman/httpfile"
+)
+
+// convertString parses and converts .http content held in memory
+func convertString(t *testing.T, content string, opts Options) Collection {
+	file, err := httpfile.Parse(strings.NewReader(content), httpfile.Options{})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	collection, err := Convert(file, opts)
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	return collection
+}
+
+func TestConvertWithEnvironment(t *testing.T) {
+	httpContent := `GET https://{{host}}/users
+Authorization: Bearer {{token}}
+`
+
+	env := httpfile.Environment{
+		"dev":  {"host": "dev.example.com", "token": "dev-token"},
+		"prod": {"host": "api.example.com", "token": "prod-token"},
+	}
+
+	collection := convertString(t, httpContent, Options{Environment: env, EnvName: "prod"})
+
+	if len(collection.Items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(collection.Items))
+	}
+
+	expected := map[string]string{"host": "api.example.com", "token": "prod-token"}
+	if len(collection.Variable) != len(expected) {
+		t.Fatalf("Expected %d variables, got %v", len(expected), collection.Variable)
+	}
+
+	for _, v := range collection.Variable {
+		if expected[v.Key] != v.Value {
+			t.Errorf("Expected variable %s='%s', got '%s'", v.Key, expected[v.Key], v.Value)
+		}
+	}
+}
+
+func TestConvertWithoutEnvironment(t *testing.T) {
+	httpContent := `GET https://api.example.com/users/{{id}}
+`
+
+	collection := convertString(t, httpContent, Options{})
+
+	if len(collection.Variable) != 1 || collection.Variable[0].Key != "id" || collection.Variable[0].Value != "" {
+		t.Errorf("Expected empty variable 'id', got %v", collection.Variable)
+	}
+}
+
+func TestConvertPrivateEnvironment(t *testing.T) {
+	httpContent := `GET https://{{host}}/users
+Authorization: Bearer {{token}}
+`
+
+	opts := Options{
+		Environment:        httpfile.Environment{"dev": {"host": "dev.example.com"}},
+		PrivateEnvironment: httpfile.Environment{"dev": {"token": "dev-token"}},
+		EnvName:            "dev",
+	}
+
+	variables := convertString(t, httpContent, opts).Variable
+	if variables[0].Type != "string" || variables[1].Value != "dev-token" || variables[1].Type != "secret" {
+		t.Errorf("Expected token as a secret variable, got %v", variables)
+	}
+
+	opts.BlankSecrets = true
+	variables = convertString(t, httpContent, opts).Variable
+	if variables[0].Value != "dev.example.com" || variables[1].Value != "" || variables[1].Type != "secret" {
+		t.Errorf("Expected a blank secret token, got %v", variables)
+	}
+}
+
+func TestConvertUnknownEnvironment(t *testing.T) {
+	file, err := httpfile.Parse(strings.NewReader("GET https://{{host}}/users\n"), httpfile.Options{})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	env := httpfile.Environment{"prod": {}, "dev": {}}
+	_, err = Convert(file, Options{Environment: env, EnvName: "test"})
+	if err == nil || !strings.Contains(err.Error(), `"test" not found, available environments: dev, prod`) {
+		t.Errorf("Expected an unknown environment error, got %v", err)
+	}
+}
+
+func TestWriteCollection(t *testing.T) {
+	collection := convertString(t, "GET https://api.example.com/users\n", Options{})
+
+	var buf bytes.Buffer
+	if err := WriteCollection(&buf, collection); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var decoded Collection
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+
+	if decoded.Info.Schema != Schema {
+		t.Errorf("Expected schema '%s', got '%s'", Schema, decoded.Info.Schema)
+	}
+
+	if len(decoded.Items) != 1 || decoded.Items[0].Request.URL.Raw != "https://api.example.com/users" {
+		t.Errorf("Unexpected items %v", decoded.Items)
+	}
+}
+
+func TestConvertHTTPVersion(t *testing.T) {
+	httpContent := `PATCH https://api.example.com/users/1 HTTP/2
+Content-Type: application/json
+
+###
+
+HEAD https://api.example.com/users HTTP/1.1
+
+###
+
+options https://api.example.com/users
+`
+
+	collection := convertString(t, httpContent, Options{})
+
+	if len(collection.Items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(collection.Items))
+	}
+
+	expected := []struct {
+		method   string
+		protocol interface{}
+	}{
+		{"PATCH", "http2"},
+		{"HEAD", "http1"},
+		{"OPTIONS", nil},
+	}
+
+	for i, e := range expected {
+		item := collection.Items[i]
+		if item.Request.Method != e.method {
+			t.Errorf("Expected method '%s', got '%s'", e.method, item.Request.Method)
+		}
+
+		if item.Request.URL.Raw != "https://api.example.com/users/1" && item.Request.URL.Raw != "https://api.example.com/users" {
+			t.Errorf("Expected the HTTP version to be stripped from the URL, got '%s'", item.Request.URL.Raw)
+		}
+
+		if item.ProtocolProfileBehavior["protocolVersion"] != e.protocol {
+			t.Errorf("Expected protocolVersion %v, got %v", e.protocol, item.ProtocolProfileBehavior)
+		}
+	}
+}
+
+func TestConvertOriginFormWithHost(t *testing.T) {
+	httpContent := `GET /users?page=2 HTTP/1.1
+Host: api.example.com:8080
+Accept: application/json
+
+###
+
+GET /health
+Host: secure.example.com:443
+
+###
+
+https://api.example.com/status
+`
+
+	collection := convertString(t, httpContent, Options{})
+
+	if len(collection.Items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(collection.Items))
+	}
+
+	first := collection.Items[0].Request
+	if first.URL.Raw != "http://api.example.com:8080/users?page=2" {
+		t.Errorf("Expected URL combined with Host header, got '%s'", first.URL.Raw)
+	}
+
+	if first.URL.Port != "8080" || len(first.URL.Host) != 3 || first.URL.Host[2] != "com" {
+		t.Errorf("Expected host api.example.com and port 8080, got %v and '%s'", first.URL.Host, first.URL.Port)
+	}
+
+	if len(first.URL.Query) != 1 || first.URL.Query[0].Key != "page" {
+		t.Errorf("Expected query param page, got %v", first.URL.Query)
+	}
+
+	if len(first.Header) != 1 || first.Header[0].Key != "Accept" {
+		t.Errorf("Expected the Host header to be removed, got %v", first.Header)
+	}
+
+	second := collection.Items[1].Request
+	if second.URL.Raw != "https://secure.example.com/health" || second.URL.Protocol != "https" {
+		t.Errorf("Expected https URL for port 443, got '%s'", second.URL.Raw)
+	}
+
+	third := collection.Items[2].Request
+	if third.Method != "GET" || third.URL.Raw != "https://api.example.com/status" {
+		t.Errorf("Expected GET https://api.example.com/status, got %s %s", third.Method, third.URL.Raw)
+	}
+}
@@ -0,0 +1,102 @@
+package postman
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// dynamicVariableRegex matches JetBrains dynamic variables: {{$uuid}}, {{$random.integer(1, 10)}}, ...
+var dynamicVariableRegex = regexp.MustCompile(`\{\{\s*\$([\w.]+)(?:\(([^)]*)\))?\s*\}\}`)
+
+// dynamicVariables maps JetBrains dynamic variables to their Postman equivalents
+var dynamicVariables = map[string]string{
+	"uuid":                      "$guid",
+	"random.uuid":               "$guid",
+	"timestamp":                 "$timestamp",
+	"isoTimestamp":              "$isoTimestamp",
+	"randomInt":                 "$randomInt",
+	"random.email":              "$randomEmail",
+	"random.internet.email":     "$randomEmail",
+	"random.internet.url":       "$randomUrl",
+	"random.internet.ipV4":      "$randomIP",
+	"random.internet.userName":  "$randomUserName",
+	"random.name.firstName":     "$randomFirstName",
+	"random.name.lastName":      "$randomLastName",
+	"random.name.fullName":      "$randomFullName",
+	"random.address.city":       "$randomCity",
+	"random.address.country":    "$randomCountry",
+	"random.address.streetName": "$randomStreetName",
+	"random.phoneNumber.phone":  "$randomPhoneNumber",
+	"random.company.name":       "$randomCompanyName",
+	"random.lorem.word":         "$randomWord",
+	"random.lorem.sentence":     "$randomLoremSentence",
+	"random.bool":               "$randomBoolean",
+}
+
+// Character sets used by the generated snippets
+const (
+	alphabeticChars   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	alphanumericChars = alphabeticChars + "0123456789_"
+	hexadecimalChars  = "0123456789abcdef"
+)
+
+// dynamicSnippets generate a value in a pre-request script for dynamic variables without a
+// Postman equivalent. The argument is the text between the parentheses.
+var dynamicSnippets = map[string]func(args string) string{
+	"random.integer": func(args string) string {
+		return "_.random(" + defaultArgs(args, "0, 1000") + ")"
+	},
+	"random.float": func(args string) string {
+		return "_.random(" + defaultArgs(args, "0, 1000") + ", true)"
+	},
+	"random.alphabetic": func(args string) string {
+		return randomStringSnippet(args, alphabeticChars)
+	},
+	"random.alphanumeric": func(args string) string {
+		return randomStringSnippet(args, alphanumericChars)
+	},
+	"random.hexadecimal": func(args string) string {
+		return randomStringSnippet(args, hexadecimalChars)
+	},
+}
+
+// dynamicTranslator replaces dynamic variables in the texts of one request and collects
+// the pre-request snippets it needs
+type dynamicTranslator struct {
+	prefix   string // Prefix of the snippet variable names
+	snippets []string
+}
+
+// replace translates every dynamic variable in text, unknown ones are left untouched
+func (d *dynamicTranslator) replace(text string) string {
+	return dynamicVariableRegex.ReplaceAllStringFunc(text, func(match string) string {
+		groups := dynamicVariableRegex.FindStringSubmatch(match)
+		name, args := groups[1], strings.TrimSpace(groups[2])
+
+		if postmanName, ok := dynamicVariables[name]; ok {
+			return "{{" + postmanName + "}}"
+		}
+
+		if snippet, ok := dynamicSnippets[name]; ok {
+			variable := fmt.Sprintf("%s%s%d", d.prefix, strings.ReplaceAll(name, ".", "_"), len(d.snippets)+1)
+			d.snippets = append(d.snippets, fmt.Sprintf("pm.variables.set(%q, %s);", variable, snippet(args)))
+			return "{{" + variable + "}}"
+		}
+
+		return match
+	})
+}
+
+// defaultArgs returns args, or fallback when no arguments were given
+func defaultArgs(args, fallback string) string {
+	if args == "" {
+		return fallback
+	}
+	return args
+}
+
+// randomStringSnippet builds a random string of the length given in args from chars
+func randomStringSnippet(args, chars string) string {
+	return fmt.Sprintf("_.times(%s, () => _.sample(%q)).join(\"\")", defaultArgs(args, "10"), chars)
+}
@@ -0,0 +1,104 @@
+package postman
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDynamicTranslatorReplace(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"{{$uuid}}", "{{$guid}}"},
+		{"{{$random.uuid}}", "{{$guid}}"},
+		{"ts={{$timestamp}}&iso={{$isoTimestamp}}", "ts={{$timestamp}}&iso={{$isoTimestamp}}"},
+		{"{{$randomInt}}", "{{$randomInt}}"},
+		{"{{ $random.email }}", "{{$randomEmail}}"},
+		{"{{$unknownThing}}", "{{$unknownThing}}"},
+		{"{{userId}}", "{{userId}}"},
+	}
+
+	for _, tt := range tests {
+		d := &dynamicTranslator{}
+		if actual := d.replace(tt.text); actual != tt.expected {
+			t.Errorf("replace(%q): expected %q, got %q", tt.text, tt.expected, actual)
+		}
+		if len(d.snippets) != 0 {
+			t.Errorf("replace(%q): expected no snippets, got %v", tt.text, d.snippets)
+		}
+	}
+}
+
+func TestConvertDynamicVariables(t *testing.T) {
+	httpContent := `POST https://api.example.com/orders/{{$uuid}}?n={{$random.integer(1, 100)}}
+X-Request-Id: {{$random.uuid}}
+Content-Type: application/json
+
+{"code": "{{$random.alphabetic(8)}}", "at": {{$timestamp}}}
+
+> {% client.test("ok", function() {}); %}
+`
+
+	collection := convertString(t, httpContent, Options{})
+
+	item := collection.Items[0]
+	if item.Request.URL.Raw != "https://api.example.com/orders/{{$guid}}?n={{random_integer1}}" {
+		t.Errorf("Unexpected URL '%s'", item.Request.URL.Raw)
+	}
+
+	if item.Request.Header[0].Value != "{{$guid}}" {
+		t.Errorf("Expected header value {{$guid}}, got '%s'", item.Request.Header[0].Value)
+	}
+
+	if item.Request.Body.Raw != `{"code": "{{random_alphabetic2}}", "at": {{$timestamp}}}` {
+		t.Errorf("Unexpected body '%s'", item.Request.Body.Raw)
+	}
+
+	if len(item.Event) != 2 || item.Event[0].Listen != "prerequest" {
+		t.Fatalf("Expected a generated prerequest event before the test event, got %+v", item.Event)
+	}
+
+	expected := `pm.variables.set("random_integer1", _.random(1, 100));
+pm.variables.set("random_alphabetic2", _.times(8, () => _.sample("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")).join(""));`
+	if exec := strings.Join(item.Event[0].Script.Exec, "\n"); exec != expected {
+		t.Errorf("Expected snippets:\n%s\ngot:\n%s", expected, exec)
+	}
+
+	if len(collection.Variable) != 0 {
+		t.Errorf("Expected no collection variables for dynamic variables, got %v", collection.Variable)
+	}
+}
+
+func TestConvertDynamicVariablesInFileVariables(t *testing.T) {
+	httpContent := `@id = {{$uuid}}
+@count = {{$random.integer(1, 10)}}
+@home = {{$env.HOME_DIR}}
+GET https://api.example.com/items/{{id}}?count={{count}}&home={{home}}
+`
+
+	collection := convertString(t, httpContent, Options{})
+
+	values := make(map[string]string)
+	for _, v := range collection.Variable {
+		values[v.Key] = v.Value
+	}
+	expected := map[string]string{
+		"id":       "{{$guid}}",
+		"count":    "{{collection_random_integer1}}",
+		"home":     "{{HOME_DIR}}",
+		"HOME_DIR": "",
+	}
+	for key, value := range expected {
+		if actual, ok := values[key]; !ok || actual != value {
+			t.Errorf("Expected collection variable %s=%q, got %q", key, value, actual)
+		}
+	}
+
+	if len(collection.Event) != 1 || collection.Event[0].Listen != "prerequest" {
+		t.Fatalf("Expected a collection prerequest event, got %+v", collection.Event)
+	}
+	if exec := strings.Join(collection.Event[0].Script.Exec, "\n"); !strings.Contains(exec, `pm.variables.set("collection_random_integer1", _.random(1, 10));`) {
+		t.Errorf("Expected the collection event to set collection_random_integer1, got:\n%s", exec)
+	}
+}
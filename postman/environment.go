@@ -0,0 +1,63 @@
+package postman
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/FrantPRO/jetbrains-http-to-postman/httpfile"
+)
+
+// EnvironmentScope marks a Postman export as an environment
+const EnvironmentScope = "environment"
+
+// Environment is a Postman environment
+type Environment struct {
+	Name   string             `json:"name"`
+	Values []EnvironmentValue `json:"values"`
+	Scope  string             `json:"_postman_variable_scope"`
+}
+
+// EnvironmentValue is a single variable of an environment
+type EnvironmentValue struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Environments converts every environment of opts.Environment and opts.PrivateEnvironment into
+// a Postman environment, sorted by name. Private values are marked as secrets.
+func Environments(opts Options) []Environment {
+	env := httpfile.MergeEnvironments(opts.Environment, opts.PrivateEnvironment)
+	var environments []Environment
+	for _, name := range env.Names() {
+		keys := make([]string, 0, len(env[name]))
+		for key := range env[name] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		values := []EnvironmentValue{}
+		for _, key := range keys {
+			value, varType := env[name][key], "default"
+			if _, secret := opts.PrivateEnvironment[name][key]; secret {
+				value, varType = environmentValue(value, true, opts.BlankSecrets)
+			}
+			values = append(values, EnvironmentValue{Key: key, Value: value, Type: varType, Enabled: true})
+		}
+		environments = append(environments, Environment{Name: name, Values: values, Scope: EnvironmentScope})
+	}
+	return environments
+}
+
+// WriteEnvironment writes the environment to w as indented JSON
+func WriteEnvironment(w io.Writer, environment Environment) error {
+	output, err := json.MarshalIndent(environment, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(output)
+	return err
+}
@@ -0,0 +1,62 @@
+package postman
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/FrantPRO/jetbrains-http-to-postman/httpfile"
+)
+
+func TestEnvironments(t *testing.T) {
+	env := httpfile.Environment{
+		"prod":  {"token": "prod-token", "host": "api.example.com"},
+		"local": {"host": "localhost:8080"},
+	}
+
+	environments := Environments(Options{Environment: env})
+	if len(environments) != 2 || environments[0].Name != "local" || environments[1].Name != "prod" {
+		t.Fatalf("Expected local and prod environments, got %+v", environments)
+	}
+
+	prod := environments[1]
+	if len(prod.Values) != 2 || prod.Values[0].Key != "host" || prod.Values[1].Value != "prod-token" {
+		t.Errorf("Expected sorted prod values, got %+v", prod.Values)
+	}
+	if !prod.Values[0].Enabled || prod.Values[0].Type != "default" {
+		t.Errorf("Expected enabled default values, got %+v", prod.Values[0])
+	}
+}
+
+func TestEnvironmentsPrivateValues(t *testing.T) {
+	opts := Options{
+		Environment:        httpfile.Environment{"dev": {"host": "dev.example.com", "token": "placeholder"}},
+		PrivateEnvironment: httpfile.Environment{"dev": {"token": "dev-token"}},
+	}
+
+	values := Environments(opts)[0].Values
+	if values[0].Type != "default" || values[1].Value != "dev-token" || values[1].Type != "secret" {
+		t.Errorf("Expected the private token as a secret, got %+v", values)
+	}
+
+	opts.BlankSecrets = true
+	values = Environments(opts)[0].Values
+	if values[0].Value != "dev.example.com" || values[1].Value != "" || values[1].Type != "secret" {
+		t.Errorf("Expected a blank secret token, got %+v", values)
+	}
+}
+
+func TestWriteEnvironment(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteEnvironment(&buf, Environments(Options{Environment: httpfile.Environment{"dev": {"host": "dev.example.com"}}})[0]); err != nil {
+		t.Fatalf("WriteEnvironment failed: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if decoded["_postman_variable_scope"] != "environment" || decoded["name"] != "dev" {
+		t.Errorf("Expected a dev environment export, got %v", decoded)
+	}
+}
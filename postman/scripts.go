@@ -0,0 +1,212 @@
+package postman
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/FrantPRO/jetbrains-http-to-postman/httpfile"
+)
+
+// scriptTranslation rewrites one JetBrains HTTP Client API call into its Postman equivalent
+type scriptTranslation struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// argsPattern matches call arguments with at most one level of nested parentheses
+const argsPattern = `((?:[^()]|\([^()]*\))*)`
+
+// apiPrefix stops the patterns from matching inside already translated pm.* calls
+const apiPrefix = `(^|[^.\w$])`
+
+var scriptTranslations = []scriptTranslation{
+	{regexp.MustCompile(apiPrefix + `client\.test\(`), "${1}pm.test("},
+	{regexp.MustCompile(apiPrefix + `client\.log\(`), "${1}console.log("},
+	{regexp.MustCompile(apiPrefix + `client\.global\.set\(`), "${1}pm.collectionVariables.set("},
+	{regexp.MustCompile(apiPrefix + `client\.global\.get\(`), "${1}pm.collectionVariables.get("},
+	{regexp.MustCompile(apiPrefix + `request\.variables\.set\(`), "${1}pm.variables.set("},
+	{regexp.MustCompile(apiPrefix + `request\.variables\.get\(`), "${1}pm.variables.get("},
+	{regexp.MustCompile(apiPrefix + `request\.environment\.get\(`), "${1}pm.environment.get("},
+	{regexp.MustCompile(`\$random\.uuid\b`), `pm.variables.replaceIn("{{$$guid}}")`},
+	{regexp.MustCompile(`\$random\.email\b`), `pm.variables.replaceIn("{{$$randomEmail}}")`},
+	{regexp.MustCompile(`\$random\.integer\(`), "_.random("},
+	{regexp.MustCompile(`\$random\.float\(([^()]*)\)`), "_.random(${1}, true)"},
+	{regexp.MustCompile(`\$random\.alphabetic\(([^()]*)\)`), `_.times(${1}, () => _.sample("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")).join("")`},
+	{regexp.MustCompile(`\$random\.hexadecimal\(([^()]*)\)`), `_.times(${1}, () => _.sample("0123456789abcdef")).join("")`},
+	{regexp.MustCompile(apiPrefix + `response\.status\b`), "${1}pm.response.code"},
+	{regexp.MustCompile(apiPrefix + `response\.body\b`), "${1}pm.response.json()"},
+	{regexp.MustCompile(apiPrefix + `response\.headers\.valueOf\(`), "${1}pm.response.headers.get("},
+}
+
+func init() {
+	// crypto.sha256().updateWithText(text).digest().toHex() -> CryptoJS.SHA256(text).toString(CryptoJS.enc.Hex)
+	for _, algorithm := range []string{"MD5", "SHA1", "SHA256", "SHA384", "SHA512"} {
+		name := strings.ToLower(algorithm)
+		scriptTranslations = append(scriptTranslations,
+			scriptTranslation{
+				regexp.MustCompile(`\bcrypto\.` + name + `\(\)\.updateWithText\(` + argsPattern + `\)\.digest\(\)`),
+				"CryptoJS." + algorithm + "(${1})",
+			},
+			scriptTranslation{
+				regexp.MustCompile(`\bcrypto\.hmac\.` + name + `\(\)\.withTextSecret\(` + argsPattern + `\)\.updateWithText\(` + argsPattern + `\)\.digest\(\)`),
+				"CryptoJS.Hmac" + algorithm + "(${2}, ${1})",
+			},
+		)
+	}
+	scriptTranslations = append(scriptTranslations,
+		scriptTranslation{regexp.MustCompile(`(CryptoJS\.\w+\(` + argsPattern + `\))\.toHex\(\)`), "${1}.toString(CryptoJS.enc.Hex)"},
+		scriptTranslation{regexp.MustCompile(`(CryptoJS\.\w+\(` + argsPattern + `\))\.toBase64\(\)`), "${1}.toString(CryptoJS.enc.Base64)"},
+	)
+}
+
+// untranslatedPrefix marks original lines that could not be translated
+const untranslatedPrefix = "// Not converted: "
+
+var (
+	assertRegex       = regexp.MustCompile(apiPrefix + `client\.assert\(`)
+	untranslatedRegex = regexp.MustCompile(apiPrefix + `(client|response|request)\.`)
+)
+
+// translateScripts translates every script of the given kind in the block into Postman exec lines.
+// Script files are read relative to the .http file.
+func (c *converter) translateScripts(block *httpfile.RequestBlock, kind httpfile.ScriptKind) ([]string, error) {
+	var exec []string
+	for _, script := range block.Scripts {
+		if script.Kind != kind {
+			continue
+		}
+
+		source := script.Source
+		if script.FilePath != "" {
+			data, err := os.ReadFile(c.resolvePath(script.FilePath))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: failed to read script file: %v", script.Pos.Line, err)
+			}
+			source = strings.TrimRight(string(data), "\r\n")
+		}
+		c.producedVariables = append(c.producedVariables, httpfile.GlobalVariables(source)...)
+		exec = append(exec, translateScript(source)...)
+	}
+	return exec, nil
+}
+
+// translateScript translates a JetBrains handler script into Postman script lines. Lines that
+// still use the JetBrains API after translation are kept as comments, along with the following
+// lines of the same call.
+func translateScript(source string) []string {
+	original := strings.Split(source, "\n")
+	lines := strings.Split(translateAsserts(source), "\n") // Asserts may span several lines
+
+	var exec []string
+	depth := 0 // Parentheses left open by the commented out lines
+	for i, line := range lines {
+		translated, ok := translateCode(line)
+		if ok && depth == 0 {
+			exec = append(exec, translated)
+			continue
+		}
+		exec = append(exec, commentOut(original[i]))
+		if depth += parenBalance(original[i]); depth < 0 {
+			depth = 0
+		}
+	}
+	return exec
+}
+
+// translateLine translates a single script line
+func translateLine(line string) string {
+	translated, ok := translateCode(translateAsserts(line))
+	if !ok {
+		return commentOut(line)
+	}
+	return translated
+}
+
+// translateCode applies the API translations, it reports false when JetBrains API calls remain
+func translateCode(code string) (string, bool) {
+	for _, t := range scriptTranslations {
+		code = t.pattern.ReplaceAllString(code, t.replacement)
+	}
+	return code, !untranslatedRegex.MatchString(code)
+}
+
+// commentOut keeps a line that could not be translated as a comment, with its indentation
+func commentOut(line string) string {
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	return indent + untranslatedPrefix + strings.TrimSpace(line)
+}
+
+// translateAsserts rewrites client.assert(condition, message) calls into
+// pm.expect(condition, message).to.be.true. Unterminated calls are left alone.
+func translateAsserts(line string) string {
+	for {
+		loc := assertRegex.FindStringSubmatchIndex(line)
+		if loc == nil {
+			return line
+		}
+
+		start := loc[3] // End of the prefix group, where client.assert begins
+		argsStart := loc[1]
+		argsEnd := closingParen(line, argsStart)
+		if argsEnd < 0 {
+			return line
+		}
+
+		line = line[:start] + "pm.expect(" + line[argsStart:argsEnd] + ").to.be.true" + line[argsEnd+1:]
+	}
+}
+
+// parenBalance returns the number of parentheses a line opens minus the ones it closes,
+// skipping string literals
+func parenBalance(line string) int {
+	balance := 0
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'' || ch == '`':
+			quote = ch
+		case ch == '(':
+			balance++
+		case ch == ')':
+			balance--
+		}
+	}
+	return balance
+}
+
+// closingParen returns the index of the parenthesis closing the call whose arguments start at
+// start, skipping string literals, or -1 when it isn't in the text
+func closingParen(line string, start int) int {
+	depth := 0
+	var quote byte
+	for i := start; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'' || ch == '`':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
@@ -0,0 +1,168 @@
+package postman
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTranslateLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{
+			`client.test("Request executed successfully", function() {`,
+			`pm.test("Request executed successfully", function() {`,
+		},
+		{
+			`    client.assert(response.status === 200, "Response status is not 200");`,
+			`    pm.expect(pm.response.code === 200, "Response status is not 200").to.be.true;`,
+		},
+		{
+			`var id = response.body.id;`,
+			`var id = pm.response.json().id;`,
+		},
+		{
+			`client.log(response.headers.valueOf("Content-Type"));`,
+			`console.log(pm.response.headers.get("Content-Type"));`,
+		},
+		{
+			`client.global.set("id", response.body.id);`,
+			`pm.collectionVariables.set("id", pm.response.json().id);`,
+		},
+		{
+			`  var type = response.contentType.mimeType;`,
+			`  // Not converted: var type = response.contentType.mimeType;`,
+		},
+		{
+			`});`,
+			`});`,
+		},
+		{
+			`request.variables.set("id", $random.uuid);`,
+			`pm.variables.set("id", pm.variables.replaceIn("{{$guid}}"));`,
+		},
+		{
+			`var n = $random.integer(1, 10);`,
+			`var n = _.random(1, 10);`,
+		},
+		{
+			`var hash = crypto.sha256().updateWithText(request.variables.get("id")).digest().toHex();`,
+			`var hash = CryptoJS.SHA256(pm.variables.get("id")).toString(CryptoJS.enc.Hex);`,
+		},
+		{
+			`var sig = crypto.hmac.sha1().withTextSecret("key").updateWithText("data").digest().toBase64();`,
+			`var sig = CryptoJS.HmacSHA1("data", "key").toString(CryptoJS.enc.Base64);`,
+		},
+		{
+			`request.headers.findByName("X-Trace");`,
+			`// Not converted: request.headers.findByName("X-Trace");`,
+		},
+	}
+
+	for _, tt := range tests {
+		if actual := translateLine(tt.line); actual != tt.expected {
+			t.Errorf("translateLine(%q):\nexpected %q\ngot      %q", tt.line, tt.expected, actual)
+		}
+	}
+}
+
+func TestTranslateScriptMultiLineCalls(t *testing.T) {
+	source := `client.assert(
+    response.status === 200,
+    "bad");
+client.exit(
+    "done");
+client.log("ok");`
+
+	expected := []string{
+		`pm.expect(`,
+		`    pm.response.code === 200,`,
+		`    "bad").to.be.true;`,
+		`// Not converted: client.exit(`,
+		`    // Not converted: "done");`,
+		`console.log("ok");`,
+	}
+
+	exec := translateScript(source)
+	if len(exec) != len(expected) {
+		t.Fatalf("Expected %d lines, got %q", len(expected), exec)
+	}
+	for i, line := range expected {
+		if exec[i] != line {
+			t.Errorf("Line %d:\nexpected %q\ngot      %q", i+1, line, exec[i])
+		}
+	}
+}
+
+func TestConvertResponseHandler(t *testing.T) {
+	httpContent := `GET https://api.example.com/users
+Accept: application/json
+
+> {%
+    client.test("Status is 200", function() {
+        client.assert(response.status === 200, "Unexpected status");
+    });
+%}
+
+###
+
+GET https://api.example.com/health
+> {% client.assert(response.body.ok, "Not healthy"); %}
+`
+
+	collection := convertString(t, httpContent, Options{})
+
+	if len(collection.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(collection.Items))
+	}
+
+	first := collection.Items[0]
+	if first.Request.Body.Mode != "" {
+		t.Errorf("Expected the handler not to become a body, got %+v", first.Request.Body)
+	}
+
+	if len(first.Event) != 1 || first.Event[0].Listen != "test" {
+		t.Fatalf("Expected one test event, got %+v", first.Event)
+	}
+
+	expected := `    pm.test("Status is 200", function() {
+        pm.expect(pm.response.code === 200, "Unexpected status").to.be.true;
+    });`
+	if exec := strings.Join(first.Event[0].Script.Exec, "\n"); exec != expected {
+		t.Errorf("Expected script:\n%s\ngot:\n%s", expected, exec)
+	}
+
+	second := collection.Items[1]
+	if len(second.Event) != 1 || second.Event[0].Script.Exec[0] != `pm.expect(pm.response.json().ok, "Not healthy").to.be.true;` {
+		t.Errorf("Unexpected single line handler %+v", second.Event)
+	}
+
+	if len(second.Request.Header) != 0 {
+		t.Errorf("Expected the handler not to become a header, got %v", second.Request.Header)
+	}
+}
+
+func TestConvertPreRequestScript(t *testing.T) {
+	httpContent := `< {%
+    request.variables.set("userId", "42");
+    client.global.set("requestedAt", Date.now().toString());
+%}
+GET https://{{baseUrl}}/users/{{userId}}
+`
+
+	collection := convertString(t, httpContent, Options{})
+
+	item := collection.Items[0]
+	if len(item.Event) != 1 || item.Event[0].Listen != "prerequest" {
+		t.Fatalf("Expected one prerequest event, got %+v", item.Event)
+	}
+
+	expected := []string{
+		`    pm.variables.set("userId", "42");`,
+		`    pm.collectionVariables.set("requestedAt", Date.now().toString());`,
+	}
+	if strings.Join(item.Event[0].Script.Exec, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected script %q, got %q", expected, item.Event[0].Script.Exec)
+	}
+}
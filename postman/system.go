@@ -0,0 +1,42 @@
+package postman
+
+import (
+	"regexp"
+	"strings"
+)
+
+// systemVariableRegex matches {{$env.NAME}}, {{$processEnv NAME}}, {{$processEnv.NAME}} and {{$dotenv NAME}}
+var systemVariableRegex = regexp.MustCompile(`\{\{\s*\$(env\.|processEnv(?:\.|\s+)|dotenv\s+)(\w+)\s*\}\}`)
+
+// replaceVariables translates the system and dynamic variables in a text sent with the request
+func (c *converter) replaceVariables(text string) string {
+	return c.dynamic.replace(c.replaceSystemVariables(text))
+}
+
+// replaceLocalVariable translates the system and dynamic variables of a @name = value declaration,
+// the snippets it needs run in the collection prerequest event
+func (c *converter) replaceLocalVariable(value string) string {
+	return c.collectionDynamic.replace(c.replaceSystemVariables(value))
+}
+
+// replaceSystemVariables resolves system variables or turns them into {{NAME}} collection variables.
+// Variables that can't be resolved become collection variables as well.
+func (c *converter) replaceSystemVariables(text string) string {
+	return systemVariableRegex.ReplaceAllStringFunc(text, func(match string) string {
+		groups := systemVariableRegex.FindStringSubmatch(match)
+		source, name := groups[1], groups[2]
+
+		if c.systemMode == SystemVariablesResolve {
+			values := c.processEnv
+			if strings.HasPrefix(source, "dotenv") {
+				values = c.dotEnv
+			}
+			if value, ok := values[name]; ok {
+				return value
+			}
+		}
+
+		c.systemVariables = append(c.systemVariables, name)
+		return "{{" + name + "}}"
+	})
+}
@@ -0,0 +1,51 @@
+package postman
+
+import "testing"
+
+func TestConvertSystemVariables(t *testing.T) {
+	httpContent := `GET https://api.example.com/users
+X-Api-Key: {{$env.API_KEY}}
+X-Build: {{$processEnv.BUILD_ID}}
+X-Secret: {{$dotenv API_SECRET}}
+X-Home: {{$processEnv HOME_DIR}}
+`
+
+	// Placeholder mode declares empty collection variables
+	collection := convertString(t, httpContent, Options{})
+
+	headers := collection.Items[0].Request.Header
+	expected := []string{"{{API_KEY}}", "{{BUILD_ID}}", "{{API_SECRET}}", "{{HOME_DIR}}"}
+	for i, value := range expected {
+		if headers[i].Value != value {
+			t.Errorf("Expected header value '%s', got '%s'", value, headers[i].Value)
+		}
+	}
+
+	if len(collection.Variable) != 4 {
+		t.Fatalf("Expected 4 collection variables, got %v", collection.Variable)
+	}
+	for _, v := range collection.Variable {
+		if v.Value != "" {
+			t.Errorf("Expected empty value for %s, got '%s'", v.Key, v.Value)
+		}
+	}
+
+	// Resolve mode substitutes the values, unknown ones stay variables
+	collection = convertString(t, httpContent, Options{
+		SystemVariables: SystemVariablesResolve,
+		ProcessEnv:      map[string]string{"API_KEY": "key-1", "HOME_DIR": "/home/dev"},
+		DotEnv:          map[string]string{"API_SECRET": "secret-1"},
+	})
+
+	headers = collection.Items[0].Request.Header
+	expected = []string{"key-1", "{{BUILD_ID}}", "secret-1", "/home/dev"}
+	for i, value := range expected {
+		if headers[i].Value != value {
+			t.Errorf("Expected header value '%s', got '%s'", value, headers[i].Value)
+		}
+	}
+
+	if len(collection.Variable) != 1 || collection.Variable[0].Key != "BUILD_ID" {
+		t.Errorf("Expected only BUILD_ID as collection variable, got %v", collection.Variable)
+	}
+}